@@ -0,0 +1,103 @@
+// Package bigsum adds up products of decimal terms without overflowing, for puzzles whose inputs don't promise
+// that the answer fits in an int.
+package bigsum
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Sum is an overflow-checked running sum of products. It sticks to plain int arithmetic while everything fits, and
+// switches over to math/big the first time a term, product or sum would overflow. The zero value is an empty sum
+// ready for use.
+type Sum struct {
+	small int
+	// large holds the sum once it has overflowed small, and is nil until then
+	large *big.Int
+}
+
+// AddProduct multiplies the two decimal terms together and adds the product to the sum.
+func (s *Sum) AddProduct(firstTerm string, secondTerm string) error {
+	if s.large == nil {
+		first, firstErr := strconv.Atoi(firstTerm)
+		second, secondErr := strconv.Atoi(secondTerm)
+		if firstErr == nil && secondErr == nil {
+			if product, ok := multiplyInts(first, second); ok {
+				if sum, ok := addInts(s.small, product); ok {
+					s.small = sum
+					return nil
+				}
+			}
+		}
+	}
+
+	// Either a term didn't fit in an int, or the arithmetic would have overflowed, so fall back to math/big
+	first, ok := new(big.Int).SetString(firstTerm, 10)
+	if !ok {
+		return fmt.Errorf("unable to convert term %s to integer", firstTerm)
+	}
+
+	second, ok := new(big.Int).SetString(secondTerm, 10)
+	if !ok {
+		return fmt.Errorf("unable to convert term %s to integer", secondTerm)
+	}
+
+	s.promote()
+	s.large.Add(s.large, first.Mul(first, second))
+	return nil
+}
+
+// Add adds another sum to this one.
+func (s *Sum) Add(other Sum) {
+	if s.large == nil && other.large == nil {
+		if sum, ok := addInts(s.small, other.small); ok {
+			s.small = sum
+			return
+		}
+	}
+
+	s.promote()
+	s.large.Add(s.large, other.Value())
+}
+
+// promote moves the sum over to math/big if it isn't there already.
+func (s *Sum) promote() {
+	if s.large == nil {
+		s.large = big.NewInt(int64(s.small))
+	}
+}
+
+// Value returns the sum as a new big.Int, which is safe for the caller to modify.
+func (s Sum) Value() *big.Int {
+	if s.large == nil {
+		return big.NewInt(int64(s.small))
+	}
+	return new(big.Int).Set(s.large)
+}
+
+// multiplyInts multiplies two ints, reporting false if the product overflows.
+func multiplyInts(x int, y int) (int, bool) {
+	if x == 0 || y == 0 {
+		return 0, true
+	}
+	if (x == -1 && y == math.MinInt) || (y == -1 && x == math.MinInt) {
+		return 0, false
+	}
+
+	product := x * y
+	if product/y != x {
+		return 0, false
+	}
+	return product, true
+}
+
+// addInts adds two ints, reporting false if the sum overflows.
+func addInts(x int, y int) (int, bool) {
+	sum := x + y
+	if (y > 0 && sum < x) || (y < 0 && sum > x) {
+		return 0, false
+	}
+	return sum, true
+}
@@ -0,0 +1,168 @@
+package bigsum
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestMultiplyInts(t *testing.T) {
+	tests := []struct {
+		name   string
+		x      int
+		y      int
+		want   int
+		wantOk bool
+	}{
+		{name: "small", x: 6, y: 7, want: 42, wantOk: true},
+		{name: "zero", x: 0, y: math.MinInt, want: 0, wantOk: true},
+		{name: "negative", x: -3, y: 4, want: -12, wantOk: true},
+		{name: "max by one", x: math.MaxInt, y: 1, want: math.MaxInt, wantOk: true},
+		{name: "max by minus one", x: math.MaxInt, y: -1, want: -math.MaxInt, wantOk: true},
+		// -MinInt is one more than MaxInt, and the product wraps back around to MinInt, so dividing it back out
+		// gives the original term and wouldn't catch the overflow
+		{name: "min by minus one", x: math.MinInt, y: -1, wantOk: false},
+		{name: "minus one by min", x: -1, y: math.MinInt, wantOk: false},
+		{name: "product overflow", x: math.MaxInt/2 + 1, y: 2, wantOk: false},
+		{name: "negative product overflow", x: math.MinInt/2 - 1, y: 2, wantOk: false},
+	}
+	for _, test := range tests {
+		got, ok := multiplyInts(test.x, test.y)
+		if ok != test.wantOk {
+			t.Errorf("%s: got ok %t, want %t", test.name, ok, test.wantOk)
+		}
+		if ok && got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAddInts(t *testing.T) {
+	tests := []struct {
+		name   string
+		x      int
+		y      int
+		want   int
+		wantOk bool
+	}{
+		{name: "small", x: 2, y: 3, want: 5, wantOk: true},
+		{name: "up to max", x: math.MaxInt - 1, y: 1, want: math.MaxInt, wantOk: true},
+		{name: "down to min", x: math.MinInt + 1, y: -1, want: math.MinInt, wantOk: true},
+		{name: "max and min", x: math.MaxInt, y: math.MinInt, want: -1, wantOk: true},
+		{name: "sum overflow", x: math.MaxInt, y: 1, wantOk: false},
+		{name: "sum underflow", x: math.MinInt, y: -1, wantOk: false},
+	}
+	for _, test := range tests {
+		got, ok := addInts(test.x, test.y)
+		if ok != test.wantOk {
+			t.Errorf("%s: got ok %t, want %t", test.name, ok, test.wantOk)
+		}
+		if ok && got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAddProduct(t *testing.T) {
+	maxInt := strconv.Itoa(math.MaxInt)
+	minInt := strconv.Itoa(math.MinInt)
+	// bigger is a term too long for an int, which has to go straight to math/big
+	bigger := "100000000000000000000000000000"
+
+	tests := []struct {
+		name string
+		// products are the pairs of terms to multiply and add, in order
+		products [][2]string
+		// want is the expected sum in decimal
+		want string
+		// wantLarge is whether the sum should have moved over to math/big
+		wantLarge bool
+	}{
+		{name: "empty", want: "0"},
+		{name: "small", products: [][2]string{{"2", "4"}, {"5", "5"}, {"11", "8"}}, want: "121"},
+		{name: "min by minus one", products: [][2]string{{minInt, "-1"}}, want: "9223372036854775808", wantLarge: true},
+		{name: "product overflow", products: [][2]string{{maxInt, "2"}}, want: "18446744073709551614", wantLarge: true},
+		{name: "sum overflow", products: [][2]string{{maxInt, "1"}, {"1", "1"}}, want: "9223372036854775808", wantLarge: true},
+		{name: "term too large", products: [][2]string{{bigger, "3"}}, want: "300000000000000000000000000000", wantLarge: true},
+		{
+			// Once promoted the sum stays in math/big, including for products that would fit in an int again
+			name:      "promotion then further adds",
+			products:  [][2]string{{maxInt, "1"}, {"1", "1"}, {"2", "3"}, {"-10", "1"}, {maxInt, "-1"}},
+			want:      "-3",
+			wantLarge: true,
+		},
+	}
+	for _, test := range tests {
+		var sum Sum
+		for _, product := range test.products {
+			if err := sum.AddProduct(product[0], product[1]); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		if got := sum.Value().String(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+		if gotLarge := sum.large != nil; gotLarge != test.wantLarge {
+			t.Errorf("%s: got promoted %t, want %t", test.name, gotLarge, test.wantLarge)
+		}
+	}
+}
+
+func TestAddProductInvalidTerm(t *testing.T) {
+	var sum Sum
+	if err := sum.AddProduct("12", "x"); err == nil {
+		t.Errorf("got no error for an invalid term")
+	}
+	if got := sum.Value().Int64(); got != 0 {
+		t.Errorf("got sum %d after an invalid term, want 0", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	small := func(n int) Sum { return Sum{small: n} }
+	large := func(n string) Sum {
+		value, _ := new(big.Int).SetString(n, 10)
+		return Sum{large: value}
+	}
+
+	tests := []struct {
+		name      string
+		sums      []Sum
+		want      string
+		wantLarge bool
+	}{
+		{name: "small", sums: []Sum{small(1), small(2), small(3)}, want: "6"},
+		{name: "sum overflow", sums: []Sum{small(math.MaxInt), small(1)}, want: "9223372036854775808", wantLarge: true},
+		{name: "large other", sums: []Sum{small(1), large("100000000000000000000")}, want: "100000000000000000001", wantLarge: true},
+		{
+			name:      "promotion then further adds",
+			sums:      []Sum{small(math.MaxInt), small(1), small(-1), large("-9223372036854775807")},
+			want:      "0",
+			wantLarge: true,
+		},
+	}
+	for _, test := range tests {
+		var total Sum
+		for _, sum := range test.sums {
+			total.Add(sum)
+		}
+		if got := total.Value().String(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+		if gotLarge := total.large != nil; gotLarge != test.wantLarge {
+			t.Errorf("%s: got promoted %t, want %t", test.name, gotLarge, test.wantLarge)
+		}
+	}
+}
+
+func TestValueIsACopy(t *testing.T) {
+	var sum Sum
+	if err := sum.AddProduct(strconv.Itoa(math.MaxInt), "2"); err != nil {
+		t.Fatal(err)
+	}
+	sum.Value().SetInt64(0)
+	if got := sum.Value().String(); got != "18446744073709551614" {
+		t.Errorf("changing the value changed the sum to %s", got)
+	}
+}
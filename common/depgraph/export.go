@@ -0,0 +1,70 @@
+package depgraph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// highlightColour is the colour of highlighted edges in both diagram formats.
+const highlightColour = "red"
+
+// WriteDOT writes the graph as a Graphviz DOT diagram, with the nodes in the order they were added and an arrow for
+// each edge. Edges in highlighted are drawn thicker and in red, such as the rules a print order breaks.
+func (g *Graph[K]) WriteDOT(w io.Writer, name string, highlighted map[[2]K]bool) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "digraph %s {\n", strconv.Quote(name))
+	fmt.Fprintln(out, "  rankdir=LR;")
+	for _, node := range g.nodes {
+		fmt.Fprintf(out, "  %s;\n", dotID(node))
+	}
+	for _, edge := range g.Edges() {
+		fmt.Fprintf(out, "  %s -> %s", dotID(edge[0]), dotID(edge[1]))
+		if highlighted[edge] {
+			fmt.Fprintf(out, " [color=%s, penwidth=2]", highlightColour)
+		}
+		fmt.Fprintln(out, ";")
+	}
+	fmt.Fprintln(out, "}")
+
+	return out.Flush()
+}
+
+func dotID[K Ordered](node K) string {
+	return strconv.Quote(fmt.Sprint(node))
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, which renders in Markdown on most code review sites. Nodes
+// are given IDs by the order they were added, and labelled with their value. Edges in highlighted are drawn
+// thicker and in red.
+func (g *Graph[K]) WriteMermaid(w io.Writer, highlighted map[[2]K]bool) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "flowchart LR")
+	for i, node := range g.nodes {
+		fmt.Fprintf(out, "  n%d[%s]\n", i, mermaidLabel(node))
+	}
+
+	// Mermaid styles links by the order they are written in
+	highlightedLinks := make([]string, 0)
+	for i, edge := range g.Edges() {
+		fmt.Fprintf(out, "  n%d --> n%d\n", g.index[edge[0]], g.index[edge[1]])
+		if highlighted[edge] {
+			highlightedLinks = append(highlightedLinks, strconv.Itoa(i))
+		}
+	}
+	if len(highlightedLinks) > 0 {
+		fmt.Fprintf(out, "  linkStyle %s stroke:%s,stroke-width:3px\n", strings.Join(highlightedLinks, ","),
+			highlightColour)
+	}
+
+	return out.Flush()
+}
+
+// mermaidLabel quotes the node's value, replacing the quotes Mermaid can't escape with its own entity for them.
+func mermaidLabel[K Ordered](node K) string {
+	return `"` + strings.ReplaceAll(fmt.Sprint(node), `"`, "#quot;") + `"`
+}
@@ -0,0 +1,46 @@
+package depgraph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteDiagrams(t *testing.T) {
+	g := FromRules(map[int][]int{47: {53}, 53: {29}, 29: {47}})
+	highlighted := map[[2]int]bool{{29, 47}: true}
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot, "rules", highlighted); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph "rules" {
+  rankdir=LR;
+  "29";
+  "47";
+  "53";
+  "29" -> "47" [color=red, penwidth=2];
+  "47" -> "53";
+  "53" -> "29";
+}
+`
+	if dot.String() != wantDOT {
+		t.Errorf("got DOT\n%s\nwant\n%s", dot.String(), wantDOT)
+	}
+
+	var mermaid strings.Builder
+	if err := g.WriteMermaid(&mermaid, highlighted); err != nil {
+		t.Fatal(err)
+	}
+	wantMermaid := `flowchart LR
+  n0["29"]
+  n1["47"]
+  n2["53"]
+  n0 --> n1
+  n1 --> n2
+  n2 --> n0
+  linkStyle 0 stroke:red,stroke-width:3px
+`
+	if mermaid.String() != wantMermaid {
+		t.Errorf("got Mermaid\n%s\nwant\n%s", mermaid.String(), wantMermaid)
+	}
+}
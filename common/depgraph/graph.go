@@ -0,0 +1,473 @@
+// Package depgraph models "X must come before Y" rules, like the page ordering rules of day 5, as a directed graph
+// with an edge from X to Y for each rule.
+package depgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ordered is the set of types that can be compared with <, which the graph uses to keep its output in a
+// predictable order.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// Graph is a directed graph. Nodes and edges are kept in the order they were added, so everything that lists them
+// always does so in the same order.
+type Graph[K Ordered] struct {
+	nodes []K
+	index map[K]int
+	// out and in hold the successors and predecessors of each node, by index
+	out [][]int
+	in  [][]int
+	// edges holds every edge as a pair of node indexes, to look them up quickly
+	edges map[[2]int]bool
+}
+
+// New creates an empty graph.
+func New[K Ordered]() *Graph[K] {
+	return &Graph[K]{index: make(map[K]int), edges: make(map[[2]int]bool)}
+}
+
+// FromRules builds a graph from rules in the form parsed from the day 5 input, where rules[X] lists every Y that
+// X must come before. Each rule becomes an edge from X to Y. The keys of a map come out in a random order, so they
+// are sorted first.
+func FromRules[K Ordered](rules map[K][]K) *Graph[K] {
+	keys := make([]K, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	g := New[K]()
+	for _, from := range keys {
+		for _, to := range rules[from] {
+			g.AddEdge(from, to)
+		}
+	}
+	return g
+}
+
+// AddNode adds a node with no edges, unless it is already in the graph.
+func (g *Graph[K]) AddNode(node K) {
+	g.add(node)
+}
+
+// add returns the index of the node, adding it first if it is new.
+func (g *Graph[K]) add(node K) int {
+	if i, ok := g.index[node]; ok {
+		return i
+	}
+
+	g.index[node] = len(g.nodes)
+	g.nodes = append(g.nodes, node)
+	g.out = append(g.out, nil)
+	g.in = append(g.in, nil)
+	return len(g.nodes) - 1
+}
+
+// AddEdge adds an edge from one node to another, adding the nodes too if they are new. It reports whether the
+// edge is new, an edge that is already in the graph isn't added twice.
+func (g *Graph[K]) AddEdge(from K, to K) bool {
+	f, t := g.add(from), g.add(to)
+	if g.edges[[2]int{f, t}] {
+		return false
+	}
+
+	g.edges[[2]int{f, t}] = true
+	g.out[f] = append(g.out[f], t)
+	g.in[t] = append(g.in[t], f)
+	return true
+}
+
+// RemoveEdge removes the edge from one node to another, and reports whether it was in the graph. The nodes stay
+// in the graph.
+func (g *Graph[K]) RemoveEdge(from K, to K) bool {
+	f, fromOk := g.index[from]
+	t, toOk := g.index[to]
+	if !fromOk || !toOk || !g.edges[[2]int{f, t}] {
+		return false
+	}
+
+	delete(g.edges, [2]int{f, t})
+	g.out[f] = removeIndex(g.out[f], t)
+	g.in[t] = removeIndex(g.in[t], f)
+	return true
+}
+
+func removeIndex(indexes []int, index int) []int {
+	for i, candidate := range indexes {
+		if candidate == index {
+			return append(indexes[:i:i], indexes[i+1:]...)
+		}
+	}
+	return indexes
+}
+
+// Len returns the number of nodes in the graph.
+func (g *Graph[K]) Len() int {
+	return len(g.nodes)
+}
+
+// Nodes returns every node, in the order they were added.
+func (g *Graph[K]) Nodes() []K {
+	return append([]K(nil), g.nodes...)
+}
+
+// HasNode reports whether the node is in the graph.
+func (g *Graph[K]) HasNode(node K) bool {
+	_, ok := g.index[node]
+	return ok
+}
+
+// HasEdge reports whether there is an edge from one node to another.
+func (g *Graph[K]) HasEdge(from K, to K) bool {
+	f, fromOk := g.index[from]
+	t, toOk := g.index[to]
+	return fromOk && toOk && g.edges[[2]int{f, t}]
+}
+
+// Edges returns every edge as a pair of nodes, grouped by the node they start from in the order the nodes were
+// added.
+func (g *Graph[K]) Edges() [][2]K {
+	edges := make([][2]K, 0, len(g.edges))
+	for f, successors := range g.out {
+		for _, t := range successors {
+			edges = append(edges, [2]K{g.nodes[f], g.nodes[t]})
+		}
+	}
+	return edges
+}
+
+// Successors returns the nodes the node has an edge to, which are the pages that must come after it.
+func (g *Graph[K]) Successors(node K) []K {
+	i, ok := g.index[node]
+	if !ok {
+		return nil
+	}
+	return g.keys(g.out[i])
+}
+
+// Predecessors returns the nodes with an edge to the node, which are the pages that must come before it.
+func (g *Graph[K]) Predecessors(node K) []K {
+	i, ok := g.index[node]
+	if !ok {
+		return nil
+	}
+	return g.keys(g.in[i])
+}
+
+// InDegree returns the number of edges into the node.
+func (g *Graph[K]) InDegree(node K) int {
+	if i, ok := g.index[node]; ok {
+		return len(g.in[i])
+	}
+	return 0
+}
+
+// OutDegree returns the number of edges out of the node.
+func (g *Graph[K]) OutDegree(node K) int {
+	if i, ok := g.index[node]; ok {
+		return len(g.out[i])
+	}
+	return 0
+}
+
+// Reachable returns every node that can be reached from the node by following one or more edges, which are all
+// of the pages that must come after it, directly or through other pages. They are listed in the order a breadth
+// first search finds them. The node itself is only included if it is on a cycle.
+func (g *Graph[K]) Reachable(node K) []K {
+	start, ok := g.index[node]
+	if !ok {
+		return nil
+	}
+
+	seen := make([]bool, len(g.nodes))
+	queue := append([]int(nil), g.out[start]...)
+	for _, next := range queue {
+		seen[next] = true
+	}
+
+	for i := 0; i < len(queue); i++ {
+		for _, next := range g.out[queue[i]] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return g.keys(queue)
+}
+
+// Bypass looks for another way from one node to another than the edge between them, which is a path that doesn't
+// use that edge. If there is one, it returns the node the path goes through first, which is a successor of from,
+// and true. A rule between two pages is redundant when there is a bypass, as the other rules already put them in
+// order.
+func (g *Graph[K]) Bypass(from K, to K) (K, bool) {
+	var none K
+	f, fromOk := g.index[from]
+	t, toOk := g.index[to]
+	if !fromOk || !toOk {
+		return none, false
+	}
+
+	// through holds the successor of from each node was first reached through, plus one so that 0 means unseen
+	through := make([]int, len(g.nodes))
+	queue := make([]int, 0)
+	for _, next := range g.out[f] {
+		if next != t && through[next] == 0 {
+			through[next] = next + 1
+			queue = append(queue, next)
+		}
+	}
+
+	for i := 0; i < len(queue); i++ {
+		current := queue[i]
+		if current == t {
+			return g.nodes[through[current]-1], true
+		}
+		for _, next := range g.out[current] {
+			// The edge itself can be reached again by going round a cycle through from
+			if current == f && next == t {
+				continue
+			}
+			if through[next] == 0 {
+				through[next] = through[current]
+				queue = append(queue, next)
+			}
+		}
+	}
+	return none, false
+}
+
+func (g *Graph[K]) keys(indexes []int) []K {
+	keys := make([]K, len(indexes))
+	for i, index := range indexes {
+		keys[i] = g.nodes[index]
+	}
+	return keys
+}
+
+// Subgraph returns the graph made of the provided nodes and the edges between them, such as the rules that apply
+// to the pages of a single print order. Nodes that aren't in the graph are added without any edges. The nodes of
+// the subgraph are in the order they were provided.
+func (g *Graph[K]) Subgraph(nodes []K) *Graph[K] {
+	sub := New[K]()
+	for _, node := range nodes {
+		sub.AddNode(node)
+	}
+
+	for _, node := range nodes {
+		f, ok := g.index[node]
+		if !ok {
+			continue
+		}
+		for _, t := range g.out[f] {
+			if sub.HasNode(g.nodes[t]) {
+				sub.AddEdge(node, g.nodes[t])
+			}
+		}
+	}
+	return sub
+}
+
+// CycleError is returned when a graph has to be sorted but contains a cycle, so there is no order that satisfies
+// all of its edges.
+type CycleError[K Ordered] struct {
+	// Cycle lists the nodes around the cycle, where each node has an edge to the next and the last has an edge
+	// back to the first
+	Cycle []K
+}
+
+func (e *CycleError[K]) Error() string {
+	steps := make([]string, 0, len(e.Cycle)+1)
+	for _, node := range e.Cycle {
+		steps = append(steps, fmt.Sprint(node))
+	}
+	if len(e.Cycle) > 0 {
+		steps = append(steps, fmt.Sprint(e.Cycle[0]))
+	}
+	return fmt.Sprintf("the graph contains a cycle: %s", strings.Join(steps, " -> "))
+}
+
+// TopologicalSort sorts the nodes with Kahn's algorithm, so that every edge goes from a node earlier in the order to
+// one later in it. Whenever several nodes could come next, the one added to the graph first is picked. If the
+// graph contains a cycle, a *CycleError is returned.
+func (g *Graph[K]) TopologicalSort() ([]K, error) {
+	inDegree := make([]int, len(g.nodes))
+	for i := range g.nodes {
+		inDegree[i] = len(g.in[i])
+	}
+
+	// The nodes with nothing left before them are kept in order, so the next one is always the earliest added
+	ready := make([]int, 0)
+	for i, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	sorted := make([]K, 0, len(g.nodes))
+	for len(ready) > 0 {
+		next := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, g.nodes[next])
+
+		for _, t := range g.out[next] {
+			inDegree[t]--
+			if inDegree[t] == 0 {
+				ready = insertSorted(ready, t)
+			}
+		}
+	}
+
+	if len(sorted) < len(g.nodes) {
+		return nil, &CycleError[K]{Cycle: g.FindCycle()}
+	}
+	return sorted, nil
+}
+
+// insertSorted inserts the index into the sorted slice of indexes, keeping it sorted.
+func insertSorted(indexes []int, index int) []int {
+	i := sort.SearchInts(indexes, index)
+	indexes = append(indexes, 0)
+	copy(indexes[i+1:], indexes[i:])
+	indexes[i] = index
+	return indexes
+}
+
+// visit states of the nodes during a depth first search
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// TopologicalSortDFS sorts the nodes with a depth first search, so that every edge goes from a node earlier in the
+// order to one later in it. Each node is placed in front of everything reachable from it. The order can differ
+// from TopologicalSort when the rules allow more than one. If the graph contains a cycle, a *CycleError is
+// returned.
+func (g *Graph[K]) TopologicalSortDFS() ([]K, error) {
+	state := make([]int, len(g.nodes))
+	// Nodes are added once everything after them has been, so the order is built back to front
+	reversed := make([]int, 0, len(g.nodes))
+	path := make([]int, 0)
+
+	var visit func(node int) []int
+	visit = func(node int) []int {
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, next := range g.out[node] {
+			switch state[next] {
+			case visiting:
+				// The next node is still on the path, so the path from it back to here is a cycle
+				for i, onPath := range path {
+					if onPath == next {
+						return append([]int(nil), path[i:]...)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+		reversed = append(reversed, node)
+		return nil
+	}
+
+	for node := range g.nodes {
+		if state[node] != unvisited {
+			continue
+		}
+		if cycle := visit(node); cycle != nil {
+			return nil, &CycleError[K]{Cycle: g.keys(cycle)}
+		}
+	}
+
+	sorted := make([]K, len(reversed))
+	for i, node := range reversed {
+		sorted[len(reversed)-1-i] = g.nodes[node]
+	}
+	return sorted, nil
+}
+
+// FindCycle returns the nodes around a cycle in the graph, where each node has an edge to the next and the last
+// has an edge back to the first, or nil if the graph has no cycles.
+func (g *Graph[K]) FindCycle() []K {
+	if _, err := g.TopologicalSortDFS(); err != nil {
+		if cycleErr, ok := err.(*CycleError[K]); ok {
+			return cycleErr.Cycle
+		}
+	}
+	return nil
+}
+
+// StronglyConnectedComponents splits the graph into groups of nodes that can all reach each other, using Tarjan's
+// algorithm. A group of more than one node holds one or more cycles, and a node on its own is only on a cycle if it
+// has an edge to itself. The groups are listed so that edges between them only go from earlier groups to later
+// ones, and the nodes of each group are in the order they were added.
+func (g *Graph[K]) StronglyConnectedComponents() [][]K {
+	order := make([]int, len(g.nodes))
+	lowLink := make([]int, len(g.nodes))
+	onStack := make([]bool, len(g.nodes))
+	stack := make([]int, 0)
+	// Found components come out with the later ones first, as a component is complete once everything it leads to is
+	components := make([][]int, 0)
+	visits := 0
+
+	var visit func(node int)
+	visit = func(node int) {
+		visits++
+		order[node], lowLink[node] = visits, visits
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range g.out[node] {
+			if order[next] == 0 {
+				visit(next)
+				if lowLink[next] < lowLink[node] {
+					lowLink[node] = lowLink[next]
+				}
+			} else if onStack[next] && order[next] < lowLink[node] {
+				lowLink[node] = order[next]
+			}
+		}
+
+		// The node is the first one found in its component, so everything above it on the stack is in it too
+		if lowLink[node] == order[node] {
+			i := len(stack) - 1
+			for stack[i] != node {
+				i--
+			}
+			component := append([]int(nil), stack[i:]...)
+			for _, member := range component {
+				onStack[member] = false
+			}
+			stack = stack[:i]
+			sort.Ints(component)
+			components = append(components, component)
+		}
+	}
+
+	for node := range g.nodes {
+		if order[node] == 0 {
+			visit(node)
+		}
+	}
+
+	sorted := make([][]K, len(components))
+	for i, component := range components {
+		sorted[len(components)-1-i] = g.keys(component)
+	}
+	return sorted
+}
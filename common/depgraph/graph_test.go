@@ -0,0 +1,141 @@
+package depgraph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// exampleRules are the ordering rules from the day 5 example.
+var exampleRules = map[int][]int{
+	47: {53, 13, 61, 29},
+	97: {13, 61, 47, 29, 53, 75},
+	75: {29, 53, 47, 61, 13},
+	61: {13, 53, 29},
+	29: {13},
+	53: {29, 13},
+}
+
+func TestGraph(t *testing.T) {
+	g := FromRules(exampleRules)
+
+	if g.Len() != 7 {
+		t.Errorf("expected 7 nodes, got %d", g.Len())
+	}
+	if !g.HasEdge(97, 75) || g.HasEdge(75, 97) {
+		t.Errorf("expected an edge from 97 to 75 and not back")
+	}
+	if got := g.InDegree(13); got != 6 {
+		t.Errorf("expected 13 to have 6 edges into it, got %d", got)
+	}
+	if got := g.Predecessors(75); !reflect.DeepEqual(got, []int{97}) {
+		t.Errorf("expected 75 to only come after 97, got %v", got)
+	}
+	if got := g.Reachable(61); !reflect.DeepEqual(got, []int{13, 53, 29}) {
+		t.Errorf("expected 13, 53 and 29 to be reachable from 61, got %v", got)
+	}
+	if g.AddEdge(97, 75) {
+		t.Errorf("expected the edge from 97 to 75 to already be in the graph")
+	}
+
+	if !g.RemoveEdge(97, 75) || g.HasEdge(97, 75) || g.InDegree(75) != 0 {
+		t.Errorf("expected the edge from 97 to 75 to be removed")
+	}
+}
+
+func TestBypass(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    map[int][]int
+		from, to int
+		via      int
+		ok       bool
+	}{
+		{name: "through another node", rules: map[int][]int{1: {2, 3}, 2: {3}}, from: 1, to: 3, via: 2, ok: true},
+		{name: "only the edge", rules: map[int][]int{1: {2, 3}}, from: 1, to: 3},
+		// 2 only reaches 3 by going back through 1 and the edge itself
+		{name: "back through the edge", rules: map[int][]int{1: {2, 3}, 2: {1}}, from: 1, to: 3},
+		{name: "round a cycle", rules: map[int][]int{1: {3, 4}, 3: {4}, 4: {3}}, from: 1, to: 3, via: 4, ok: true},
+		{name: "no edge", rules: map[int][]int{1: {2}, 2: {3}}, from: 1, to: 3, via: 2, ok: true},
+		{name: "missing node", rules: map[int][]int{1: {2}}, from: 1, to: 5},
+	}
+	for _, test := range tests {
+		via, ok := FromRules(test.rules).Bypass(test.from, test.to)
+		if via != test.via || ok != test.ok {
+			t.Errorf("%s: got %d and %t, want %d and %t", test.name, via, ok, test.via, test.ok)
+		}
+	}
+}
+
+func TestTopologicalSort(t *testing.T) {
+	g := FromRules(exampleRules)
+	want := []int{97, 75, 47, 61, 53, 29, 13}
+
+	for name, sort := range map[string]func() ([]int, error){
+		"kahn": g.TopologicalSort,
+		"dfs":  g.TopologicalSortDFS,
+	} {
+		got, err := sort()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// The example rules allow only one order
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCycle(t *testing.T) {
+	g := FromRules(map[int][]int{1: {2}, 2: {3}, 3: {4, 1}, 4: {5}})
+
+	cycle := g.FindCycle()
+	if !reflect.DeepEqual(cycle, []int{1, 2, 3}) {
+		t.Errorf("expected the cycle 1 -> 2 -> 3 -> 1, got %v", cycle)
+	}
+
+	for name, sort := range map[string]func() ([]int, error){
+		"kahn": g.TopologicalSort,
+		"dfs":  g.TopologicalSortDFS,
+	} {
+		_, err := sort()
+		var cycleErr *CycleError[int]
+		if !errors.As(err, &cycleErr) {
+			t.Fatalf("%s: expected a CycleError, got %v", name, err)
+		}
+		if err.Error() != "the graph contains a cycle: 1 -> 2 -> 3 -> 1" {
+			t.Errorf("%s: unexpected error %q", name, err)
+		}
+	}
+
+	// Leaving out any page of the cycle breaks it
+	sub := g.Subgraph([]int{4, 3, 2, 5})
+	if cycle := sub.FindCycle(); cycle != nil {
+		t.Errorf("expected no cycle without page 1, got %v", cycle)
+	}
+	if got, err := sub.TopologicalSort(); err != nil || !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Errorf("expected the subgraph to sort to [2 3 4 5], got %v (%v)", got, err)
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := FromRules(map[int][]int{1: {2}, 2: {3}, 3: {4, 1}, 4: {5}, 5: {6}, 6: {5}, 7: {1}})
+
+	want := [][]int{{7}, {1, 2, 3}, {4}, {5, 6}}
+	if got := g.StronglyConnectedComponents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Without cycles every node is on its own, in an order that follows the edges
+	components := FromRules(exampleRules).StronglyConnectedComponents()
+	got := make([]int, 0)
+	for _, component := range components {
+		if len(component) != 1 {
+			t.Fatalf("expected every node on its own, got %v", components)
+		}
+		got = append(got, component[0])
+	}
+	if !reflect.DeepEqual(got, []int{97, 75, 47, 61, 53, 29, 13}) {
+		t.Errorf("expected the components to follow the rules, got %v", got)
+	}
+}
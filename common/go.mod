@@ -0,0 +1,8 @@
+module common
+
+go 1.20
+
+require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.21.0
+)
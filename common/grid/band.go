@@ -0,0 +1,110 @@
+package grid
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SubRows returns a view of the rows from from up to but not including to. The view shares its cells with the
+// grid, so changes to one are reflected in the other. It panics if the rows aren't inside the grid.
+func (g *Grid[T]) SubRows(from int, to int) *Grid[T] {
+	if from < 0 || to > g.rows || from > to {
+		panic(fmt.Sprintf("unable to take rows %d to %d of a grid with %d rows", from, to, g.rows))
+	}
+	return &Grid[T]{rows: to - from, cols: g.cols, cells: g.cells[from*g.cols : to*g.cols]}
+}
+
+// Band is a horizontal strip of a grid, for searching part of a grid on its own. The band owns a range of rows,
+// and can also see a number of rows either side of them, so that anything starting in its own rows and reaching
+// into the rows around them is still found. Anything starting in the rows around it belongs to another band.
+type Band[T any] struct {
+	// Grid holds the rows the band owns, along with the rows around them it can see
+	Grid *Grid[T]
+	// Offset is the row of the whole grid that row 0 of Grid was taken from
+	Offset int
+	// First and End are the rows of Grid the band owns, from First up to but not including End
+	First int
+	End   int
+}
+
+// Band returns the band owning the rows from from up to but not including to, which can see up to overlap rows
+// either side of them.
+func (g *Grid[T]) Band(from int, to int, overlap int) Band[T] {
+	start, end := from-overlap, to+overlap
+	if start < 0 {
+		start = 0
+	}
+	if end > g.rows {
+		end = g.rows
+	}
+	return Band[T]{Grid: g.SubRows(start, end), Offset: start, First: from - start, End: to - start}
+}
+
+// Bands splits the grid into n bands of roughly the same height, in order from the top of the grid, where each
+// band can see overlap rows either side of its own. There are fewer than n bands if the grid has fewer than n rows.
+func (g *Grid[T]) Bands(n int, overlap int) []Band[T] {
+	if n > g.rows {
+		n = g.rows
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	bands := make([]Band[T], n)
+	for i := range bands {
+		bands[i] = g.Band(i*g.rows/n, (i+1)*g.rows/n, overlap)
+	}
+	return bands
+}
+
+// Owns reports whether a point of the band's grid is in one of the rows the band owns.
+func (b Band[T]) Owns(p Point) bool {
+	return p.Row >= b.First && p.Row < b.End
+}
+
+// ToGrid converts a point of the band's grid into the same point of the whole grid.
+func (b Band[T]) ToGrid(p Point) Point {
+	return Point{Row: p.Row + b.Offset, Col: p.Col}
+}
+
+// bandsPerWorker is how many bands each worker gets in SearchBands. Having more bands than workers keeps every
+// worker busy until the end, even when some bands take longer to search than others.
+const bandsPerWorker = 4
+
+// SearchBands splits the grid into bands that can see overlap rows either side of their own, and searches them
+// with a pool of workers goroutines. search is called once for each band, and should only return results starting
+// in the rows the band owns, so nothing is found twice. The results of all the bands are returned in order from
+// the top of the grid, so a search that returns its results row by row gives the same results as searching the
+// whole grid at once.
+func SearchBands[T any, R any](g *Grid[T], workers int, overlap int, search func(b Band[T]) []R) []R {
+	if workers < 1 {
+		workers = 1
+	}
+	bands := g.Bands(workers*bandsPerWorker, overlap)
+
+	results := make([][]R, len(bands))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = search(bands[i])
+			}
+		}()
+	}
+
+	for i := range bands {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	merged := make([]R, 0)
+	for _, result := range results {
+		merged = append(merged, result...)
+	}
+	return merged
+}
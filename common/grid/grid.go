@@ -0,0 +1,124 @@
+// Package grid contains the building blocks shared by the grid based puzzles: points, the directions between them
+// and a rectangular grid of cells with bounds-checked access.
+package grid
+
+import "fmt"
+
+// Grid is a rectangular grid of cells. The cells are stored row by row in a single slice, which keeps them close
+// together in memory and avoids the separate allocation per row that a [][]T needs.
+type Grid[T any] struct {
+	rows  int
+	cols  int
+	cells []T
+}
+
+// New creates a grid of the provided size with every cell set to the zero value of T.
+func New[T any](rows int, cols int) *Grid[T] {
+	if rows < 0 || cols < 0 {
+		panic(fmt.Sprintf("unable to create a grid with a negative size of %dx%d", rows, cols))
+	}
+	return &Grid[T]{rows: rows, cols: cols, cells: make([]T, rows*cols)}
+}
+
+// FromRows creates a grid from a slice of rows, which must all be the same length.
+func FromRows[T any](rows [][]T) (*Grid[T], error) {
+	if len(rows) == 0 {
+		return New[T](0, 0), nil
+	}
+
+	g := New[T](len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != g.cols {
+			return nil, fmt.Errorf("row %d has %d cells, expected %d like the first row", i, len(row), g.cols)
+		}
+		copy(g.cells[i*g.cols:], row)
+	}
+
+	return g, nil
+}
+
+// Rows returns the number of rows in the grid.
+func (g *Grid[T]) Rows() int {
+	return g.rows
+}
+
+// Cols returns the number of columns in the grid.
+func (g *Grid[T]) Cols() int {
+	return g.cols
+}
+
+// InBounds reports whether the point is inside the grid.
+func (g *Grid[T]) InBounds(p Point) bool {
+	return p.Row >= 0 && p.Row < g.rows && p.Col >= 0 && p.Col < g.cols
+}
+
+// Wrap maps any point onto the grid as if the grid were a torus, where stepping off one edge brings you back in
+// on the opposite edge. An empty grid has nowhere to wrap to, so the point is returned unchanged.
+func (g *Grid[T]) Wrap(p Point) Point {
+	if g.rows == 0 || g.cols == 0 {
+		return p
+	}
+
+	row := p.Row % g.rows
+	if row < 0 {
+		row += g.rows
+	}
+	col := p.Col % g.cols
+	if col < 0 {
+		col += g.cols
+	}
+	return Point{Row: row, Col: col}
+}
+
+// Get returns the value of the cell at the point. If the point is outside the grid, it returns the zero value
+// of T and false.
+func (g *Grid[T]) Get(p Point) (T, bool) {
+	if !g.InBounds(p) {
+		var zero T
+		return zero, false
+	}
+	return g.cells[p.Row*g.cols+p.Col], true
+}
+
+// At returns the value of the cell at the point without checking that it is inside the grid, for hot loops
+// that have already checked the bounds themselves. A point outside the grid either panics or returns the value
+// of an unrelated cell.
+func (g *Grid[T]) At(p Point) T {
+	return g.cells[p.Row*g.cols+p.Col]
+}
+
+// Set sets the value of the cell at the point. If the point is outside the grid nothing is changed and
+// false is returned.
+func (g *Grid[T]) Set(p Point, value T) bool {
+	if !g.InBounds(p) {
+		return false
+	}
+	g.cells[p.Row*g.cols+p.Col] = value
+	return true
+}
+
+// Row returns the cells of a single row. The slice shares memory with the grid, so changes to it are
+// reflected in the grid. The row must be between 0 and Rows()-1, as it is when looping over the rows of the
+// grid; any other row panics or returns the cells of an unrelated row.
+func (g *Grid[T]) Row(row int) []T {
+	return g.cells[row*g.cols : (row+1)*g.cols]
+}
+
+// Neighbours returns the points next to p in each of the provided directions (usually Orthogonal or All)
+// that are inside the grid.
+func (g *Grid[T]) Neighbours(p Point, directions []Direction) []Point {
+	neighbours := make([]Point, 0, len(directions))
+	for _, d := range directions {
+		if next := p.Add(d); g.InBounds(next) {
+			neighbours = append(neighbours, next)
+		}
+	}
+	return neighbours
+}
+
+// ForEach calls fn for every cell in the grid, row by row.
+func (g *Grid[T]) ForEach(fn func(p Point, value T)) {
+	for i, value := range g.cells {
+		fn(Point{Row: i / g.cols, Col: i % g.cols}, value)
+	}
+}
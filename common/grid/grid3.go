@@ -0,0 +1,212 @@
+package grid
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Point3 is a position in a layered grid. Layers count up from 0 in the order they were loaded, and rows and
+// columns work the same way as in a Point.
+type Point3 struct {
+	Layer int
+	Row   int
+	Col   int
+}
+
+// Add returns the point one step away from p in the provided direction.
+func (p Point3) Add(d Direction3) Point3 {
+	return Point3{Layer: p.Layer + d.DLayer, Row: p.Row + d.DRow, Col: p.Col + d.DCol}
+}
+
+// Step returns the point n steps away from p in the provided direction.
+func (p Point3) Step(d Direction3, n int) Point3 {
+	return Point3{Layer: p.Layer + n*d.DLayer, Row: p.Row + n*d.DRow, Col: p.Col + n*d.DCol}
+}
+
+func (p Point3) String() string {
+	return fmt.Sprintf("(%d,%d,%d)", p.Layer, p.Row, p.Col)
+}
+
+// Direction3 is the vector between a point in a layered grid and one of its 26 neighbours.
+type Direction3 struct {
+	DLayer int
+	DRow   int
+	DCol   int
+}
+
+// All3 lists all 26 directions around a point in a layered grid: the eight directions of All within the same
+// layer first, followed by straight up a layer and those eight directions in the layer above, and the same nine
+// for the layer below.
+var All3 = func() []Direction3 {
+	directions := make([]Direction3, 0, 26)
+	for _, dLayer := range []int{0, 1, -1} {
+		if dLayer != 0 {
+			directions = append(directions, Direction3{DLayer: dLayer})
+		}
+		for _, d := range All {
+			directions = append(directions, Direction3{DLayer: dLayer, DRow: d.DRow, DCol: d.DCol})
+		}
+	}
+	return directions
+}()
+
+// Flat returns the part of the direction within a layer.
+func (d Direction3) Flat() Direction {
+	return Direction{DRow: d.DRow, DCol: d.DCol}
+}
+
+// Opposite returns the direction pointing the other way.
+func (d Direction3) Opposite() Direction3 {
+	return Direction3{DLayer: -d.DLayer, DRow: -d.DRow, DCol: -d.DCol}
+}
+
+func (d Direction3) String() string {
+	switch {
+	case d.DLayer == 0:
+		return d.Flat().String()
+	case d.DRow == 0 && d.DCol == 0:
+		return fmt.Sprintf("%+d layer", d.DLayer)
+	}
+	return fmt.Sprintf("%s %+d layer", d.Flat(), d.DLayer)
+}
+
+// Grid3 is a stack of equally sized grids, stored in a single slice layer by layer.
+type Grid3[T any] struct {
+	layers int
+	rows   int
+	cols   int
+	cells  []T
+}
+
+// New3 creates a layered grid of the provided size with every cell set to the zero value of T.
+func New3[T any](layers int, rows int, cols int) *Grid3[T] {
+	if layers < 0 || rows < 0 || cols < 0 {
+		panic(fmt.Sprintf("unable to create a grid with a negative size of %dx%dx%d", layers, rows, cols))
+	}
+	return &Grid3[T]{layers: layers, rows: rows, cols: cols, cells: make([]T, layers*rows*cols)}
+}
+
+// Layers returns the number of layers in the grid.
+func (g *Grid3[T]) Layers() int {
+	return g.layers
+}
+
+// Rows returns the number of rows in each layer.
+func (g *Grid3[T]) Rows() int {
+	return g.rows
+}
+
+// Cols returns the number of columns in each layer.
+func (g *Grid3[T]) Cols() int {
+	return g.cols
+}
+
+// InBounds reports whether the point is inside the grid.
+func (g *Grid3[T]) InBounds(p Point3) bool {
+	return p.Layer >= 0 && p.Layer < g.layers && p.Row >= 0 && p.Row < g.rows && p.Col >= 0 && p.Col < g.cols
+}
+
+func (g *Grid3[T]) index(p Point3) int {
+	return (p.Layer*g.rows+p.Row)*g.cols + p.Col
+}
+
+// Get returns the value of the cell at the point. If the point is outside the grid, it returns the zero value
+// of T and false.
+func (g *Grid3[T]) Get(p Point3) (T, bool) {
+	if !g.InBounds(p) {
+		var zero T
+		return zero, false
+	}
+	return g.cells[g.index(p)], true
+}
+
+// At returns the value of the cell at the point without checking that it is inside the grid, like Grid.At.
+func (g *Grid3[T]) At(p Point3) T {
+	return g.cells[g.index(p)]
+}
+
+// Set sets the value of the cell at the point. If the point is outside the grid nothing is changed and
+// false is returned.
+func (g *Grid3[T]) Set(p Point3, value T) bool {
+	if !g.InBounds(p) {
+		return false
+	}
+	g.cells[g.index(p)] = value
+	return true
+}
+
+// LoadLayers reads a layered grid from r. Each layer is a block of lines in the same format Load reads, and the
+// layers are separated by one or more blank lines. Every layer must be the same size, unless opts.Ragged is set,
+// in which case smaller layers are padded with opts.Blank up to the size of the largest one.
+func LoadLayers[T any](r io.Reader, split func(line string) []T, opts LoadOptions[T]) (*Grid3[T], error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]*Grid[T], 0)
+	rows, cols := 0, 0
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+
+		layer, err := fromLines(lines[start:end], start+1, split, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load layer %d due to: %w", len(layers), err)
+		}
+
+		if len(layers) == 0 || opts.Ragged {
+			if layer.Rows() > rows {
+				rows = layer.Rows()
+			}
+			if layer.Cols() > cols {
+				cols = layer.Cols()
+			}
+		} else if layer.Rows() != rows || layer.Cols() != cols {
+			return nil, fmt.Errorf("layer %d starting on line %d is %dx%d, expected %dx%d like the layers before it",
+				len(layers), start+1, layer.Rows(), layer.Cols(), rows, cols)
+		}
+
+		layers = append(layers, layer)
+		start = end
+	}
+
+	g := New3[T](len(layers), rows, cols)
+	for i, layer := range layers {
+		for row := 0; row < rows; row++ {
+			for col := 0; col < cols; col++ {
+				value, ok := layer.Get(Point{Row: row, Col: col})
+				if !ok {
+					value = opts.Blank
+				}
+				g.Set(Point3{Layer: i, Row: row, Col: col}, value)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// LoadLayersFile opens the file and reads a layered grid from it with LoadLayers.
+func LoadLayersFile[T any](filename string, split func(line string) []T, opts LoadOptions[T]) (*Grid3[T], error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	g, err := LoadLayers(file, split, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load layered grid from %s due to: %w", filename, err)
+	}
+	return g, nil
+}
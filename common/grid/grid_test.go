@@ -0,0 +1,255 @@
+package grid
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetAndSet(t *testing.T) {
+	g := New[int](2, 3)
+
+	outside := []Point{{Row: -1, Col: 0}, {Row: 0, Col: -1}, {Row: 2, Col: 0}, {Row: 0, Col: 3}}
+	for _, p := range outside {
+		if g.Set(p, 1) {
+			t.Errorf("expected setting %s outside the grid to fail", p)
+		}
+		if value, ok := g.Get(p); ok || value != 0 {
+			t.Errorf("expected getting %s outside the grid to fail, got %d and %t", p, value, ok)
+		}
+	}
+
+	if !g.Set(Point{Row: 1, Col: 2}, 7) {
+		t.Fatalf("expected setting the bottom right corner to work")
+	}
+	if value, ok := g.Get(Point{Row: 1, Col: 2}); !ok || value != 7 {
+		t.Errorf("expected 7 in the bottom right corner, got %d and %t", value, ok)
+	}
+
+	if row := g.Row(1); !reflect.DeepEqual(row, []int{0, 0, 7}) {
+		t.Errorf("expected the second row to be [0 0 7], got %v", row)
+	}
+	g.Row(0)[1] = 5
+	if value, _ := g.Get(Point{Row: 0, Col: 1}); value != 5 {
+		t.Errorf("expected a change to the first row to show up in the grid, got %d", value)
+	}
+}
+
+func TestDirections(t *testing.T) {
+	for _, d := range All {
+		if got := d.Opposite().Opposite(); got != d {
+			t.Errorf("%s: the opposite of the opposite is %s", d, got)
+		}
+		if got := d.Rotate45(4); got != d.Opposite() {
+			t.Errorf("%s: turning 180 degrees gives %s, expected %s", d, got, d.Opposite())
+		}
+		if got := d.Rotate45(3).Rotate45(-3); got != d {
+			t.Errorf("%s: turning 135 degrees and back gives %s", d, got)
+		}
+		if got := d.Rotate45(8); got != d {
+			t.Errorf("%s: turning all the way round gives %s", d, got)
+		}
+		if got := d.RotateClockwise(); got != d.Rotate45(2) {
+			t.Errorf("%s: turning clockwise gives %s, expected %s", d, got, d.Rotate45(2))
+		}
+		if got := d.RotateClockwise().RotateCounterClockwise(); got != d {
+			t.Errorf("%s: turning clockwise and back gives %s", d, got)
+		}
+
+		parsed, err := ParseDirection(d.String())
+		if err != nil || parsed != d {
+			t.Errorf("%s: parsing its name gives %s (%v)", d, parsed, err)
+		}
+	}
+
+	if Up.RotateClockwise() != Right || Up.Rotate45(-1) != UpLeft {
+		t.Errorf("expected up to turn clockwise to the right, and 45 degrees back to up-left")
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	g := New[int](3, 3)
+
+	tests := []struct {
+		p          Point
+		directions []Direction
+		want       []Point
+	}{
+		{Point{Row: 0, Col: 0}, All, []Point{{Row: 0, Col: 1}, {Row: 1, Col: 1}, {Row: 1, Col: 0}}},
+		{Point{Row: 2, Col: 2}, All, []Point{{Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 1, Col: 1}}},
+		{Point{Row: 0, Col: 2}, Orthogonal, []Point{{Row: 1, Col: 2}, {Row: 0, Col: 1}}},
+		{Point{Row: 2, Col: 0}, Diagonal, []Point{{Row: 1, Col: 1}}},
+	}
+	for _, test := range tests {
+		if got := g.Neighbours(test.p, test.directions); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.p, got, test.want)
+		}
+	}
+
+	if got := len(g.Neighbours(Point{Row: 1, Col: 1}, All)); got != 8 {
+		t.Errorf("expected the centre to have 8 neighbours, got %d", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	g := New[int](3, 4)
+
+	tests := []struct {
+		p    Point
+		want Point
+	}{
+		{Point{Row: 1, Col: 2}, Point{Row: 1, Col: 2}},
+		{Point{Row: -1, Col: -1}, Point{Row: 2, Col: 3}},
+		{Point{Row: -4, Col: -9}, Point{Row: 2, Col: 3}},
+		{Point{Row: 3, Col: 4}, Point{Row: 0, Col: 0}},
+		{Point{Row: 7, Col: -4}, Point{Row: 1, Col: 0}},
+	}
+	for _, test := range tests {
+		if got := g.Wrap(test.p); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.p, got, test.want)
+		}
+	}
+	// An empty grid has nowhere to wrap to, so points are left where they are
+	for _, empty := range []*Grid[int]{New[int](0, 0), New[int](0, 4), New[int](3, 0)} {
+		p := Point{Row: 5, Col: -2}
+		if got := empty.Wrap(p); got != p {
+			t.Errorf("%dx%d grid: got %s, want %s unchanged", empty.Rows(), empty.Cols(), got, p)
+		}
+	}
+}
+
+func TestBands(t *testing.T) {
+	rows := make([][]int, 10)
+	for i := range rows {
+		rows[i] = []int{i, i}
+	}
+	g, err := FromRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := g.SubRows(3, 5)
+	if sub.Rows() != 2 || sub.Cols() != 2 || sub.At(Point{Row: 0, Col: 0}) != 3 {
+		t.Errorf("expected rows 3 and 4, got %d rows starting with %d", sub.Rows(), sub.At(Point{Row: 0, Col: 0}))
+	}
+	sub.Set(Point{Row: 1, Col: 1}, 40)
+	if g.At(Point{Row: 4, Col: 1}) != 40 {
+		t.Errorf("expected the rows to share their cells with the grid")
+	}
+
+	// Every row is owned by exactly one band, and each band sees up to two rows either side of its own
+	bands := g.Bands(3, 2)
+	if len(bands) != 3 {
+		t.Fatalf("expected 3 bands, got %d", len(bands))
+	}
+	owned := make([]int, 0)
+	for _, b := range bands {
+		for row := 0; row < b.Grid.Rows(); row++ {
+			p := b.ToGrid(Point{Row: row})
+			if b.Grid.At(Point{Row: row}) != p.Row {
+				t.Errorf("row %d of the band at %d should be row %d of the grid", row, b.Offset, p.Row)
+			}
+			if b.Owns(Point{Row: row}) {
+				owned = append(owned, p.Row)
+			}
+		}
+	}
+	if !reflect.DeepEqual(owned, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("expected every row to be owned once, got %v", owned)
+	}
+
+	want := []struct{ offset, first, end, rows int }{{0, 0, 3, 5}, {1, 2, 5, 7}, {4, 2, 6, 6}}
+	for i, b := range bands {
+		w := want[i]
+		if b.Offset != w.offset || b.First != w.first || b.End != w.end || b.Grid.Rows() != w.rows {
+			t.Errorf("band %d: got offset %d, rows %d to %d of %d, want offset %d, rows %d to %d of %d", i, b.Offset,
+				b.First, b.End, b.Grid.Rows(), w.offset, w.first, w.end, w.rows)
+		}
+	}
+
+	if got := len(g.Bands(20, 0)); got != 10 {
+		t.Errorf("expected no more bands than rows, got %d", got)
+	}
+}
+
+func TestGrid3(t *testing.T) {
+	if len(All3) != 26 {
+		t.Fatalf("expected 26 directions, got %d", len(All3))
+	}
+	seen := make(map[Direction3]bool)
+	for _, d := range All3 {
+		if d == (Direction3{}) || seen[d] {
+			t.Errorf("%s: expected every direction to move, and to be listed once", d)
+		}
+		seen[d] = true
+	}
+	for _, d := range All3 {
+		if !seen[d.Opposite()] {
+			t.Errorf("%s: the opposite direction %s is missing", d, d.Opposite())
+		}
+	}
+
+	p := Point3{Layer: 0, Row: 1, Col: 2}
+	if got := p.Step(Direction3{DLayer: 1, DRow: -1, DCol: 1}, 2); got != (Point3{Layer: 2, Row: -1, Col: 4}) {
+		t.Errorf("expected two steps up a layer and up-right to reach (2, -1, 4), got %s", got)
+	}
+
+	g := New3[int](2, 2, 3)
+	if !g.Set(Point3{Layer: 1, Row: 1, Col: 2}, 7) {
+		t.Fatalf("expected setting the last cell to work")
+	}
+	if value, ok := g.Get(Point3{Layer: 1, Row: 1, Col: 2}); !ok || value != 7 {
+		t.Errorf("expected 7 in the last cell, got %d and %t", value, ok)
+	}
+	if value, ok := g.Get(Point3{Layer: 0, Row: 1, Col: 2}); !ok || value != 0 {
+		t.Errorf("expected the same cell of the first layer to be untouched, got %d and %t", value, ok)
+	}
+	for _, p := range []Point3{{Layer: -1}, {Layer: 2}, {Row: 2}, {Col: 3}} {
+		if g.Set(p, 1) || g.InBounds(p) {
+			t.Errorf("expected %s to be outside the grid", p)
+		}
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	g, err := LoadLayers(strings.NewReader("AB\nCD\n\n\nEF\nGH\n\n"), Bytes, LoadOptions[byte]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Layers() != 2 || g.Rows() != 2 || g.Cols() != 2 {
+		t.Fatalf("expected 2 layers of 2x2, got %d layers of %dx%d", g.Layers(), g.Rows(), g.Cols())
+	}
+	if got := g.At(Point3{Layer: 1, Row: 1, Col: 0}); got != 'G' {
+		t.Errorf("expected G in the second layer, got %c", got)
+	}
+
+	mismatched := []string{
+		"AB\nCD\n\nEF\n",
+		"AB\nCD\n\nEFG\nHIJ\n",
+	}
+	for _, input := range mismatched {
+		if _, err := LoadLayers(strings.NewReader(input), Bytes, LoadOptions[byte]{}); err == nil {
+			t.Errorf("%q: expected layers of different sizes to be rejected", input)
+		}
+	}
+
+	// A ragged line within a layer is reported like it is for a flat grid
+	_, err = LoadLayers(strings.NewReader("AB\nCD\n\nEF\nG\n"), Bytes, LoadOptions[byte]{})
+	var ragged *RaggedLineError
+	if !errors.As(err, &ragged) || ragged.Line != 5 {
+		t.Errorf("expected a ragged line error for line 5, got %v", err)
+	}
+
+	g, err = LoadLayers(strings.NewReader("AB\n\nCDE\nF\n"), Bytes, LoadOptions[byte]{Ragged: true, Blank: '.'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Layers() != 2 || g.Rows() != 2 || g.Cols() != 3 {
+		t.Fatalf("expected the ragged layers to be padded to 2x3, got %d layers of %dx%d", g.Layers(), g.Rows(),
+			g.Cols())
+	}
+	if got := g.At(Point3{Layer: 0, Row: 1, Col: 2}); got != '.' {
+		t.Errorf("expected the missing cells to be blank, got %c", got)
+	}
+}
@@ -0,0 +1,122 @@
+package grid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadOptions controls how Load turns lines of text into a grid.
+type LoadOptions[T any] struct {
+	// Ragged allows lines of different lengths. The grid is as wide as the longest line, and the cells missing
+	// from the end of shorter lines are filled with Blank. Without it, every line must be the same length.
+	Ragged bool
+	// Blank is the value of the cells missing from short lines in a ragged grid
+	Blank T
+}
+
+// RaggedLineError is returned when a line of a grid is not the same length as the lines before it.
+type RaggedLineError struct {
+	// Line is the line number of the offending line, starting from 1
+	Line int
+	// Text is the content of the offending line
+	Text string
+	// Width is the number of cells in the offending line
+	Width int
+	// Expected is the number of cells in each of the lines before it
+	Expected int
+}
+
+func (e *RaggedLineError) Error() string {
+	return fmt.Sprintf("line %d %q has %d cells, expected %d like the lines before it", e.Line, e.Text, e.Width, e.Expected)
+}
+
+// Load reads a grid from r with one row per line, using split to turn each line into its cells.
+// Blank lines at the end of the input are ignored. Unless opts.Ragged is set, a line of a different length from
+// the first one is reported as a *RaggedLineError.
+func Load[T any](r io.Reader, split func(line string) []T, opts LoadOptions[T]) (*Grid[T], error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the blank lines at the end
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return fromLines(lines, 1, split, opts)
+}
+
+// readLines reads all of the lines from r.
+func readLines(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading grid due to : %w", err)
+	}
+
+	return lines, nil
+}
+
+// fromLines builds a grid out of lines of text, where firstLine is the line number of the first line in the
+// input it came from, for error reporting.
+func fromLines[T any](lines []string, firstLine int, split func(line string) []T, opts LoadOptions[T]) (*Grid[T], error) {
+	rows := make([][]T, len(lines))
+	for i, line := range lines {
+		rows[i] = split(line)
+	}
+
+	// The first line decides the width of the grid, unless it is ragged and a longer line comes along later
+	width := 0
+	for i, row := range rows {
+		if i == 0 || (opts.Ragged && len(row) > width) {
+			width = len(row)
+		} else if !opts.Ragged && len(row) != width {
+			return nil, &RaggedLineError{Line: firstLine + i, Text: lines[i], Width: len(row), Expected: width}
+		}
+	}
+
+	g := New[T](len(rows), width)
+	for i, row := range rows {
+		cells := g.Row(i)
+		copy(cells, row)
+		for col := len(row); col < width; col++ {
+			cells[col] = opts.Blank
+		}
+	}
+
+	return g, nil
+}
+
+// LoadFile opens the file and reads a grid from it with Load.
+func LoadFile[T any](filename string, split func(line string) []T, opts LoadOptions[T]) (*Grid[T], error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	g, err := Load(file, split, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load grid from %s due to: %w", filename, err)
+	}
+	return g, nil
+}
+
+// Bytes splits a line into one cell per byte.
+func Bytes(line string) []byte {
+	return []byte(line)
+}
+
+// Characters splits a line into one cell per UTF-8 encoded character, the same way strings.Split(line, "") does.
+func Characters(line string) []string {
+	return strings.Split(line, "")
+}
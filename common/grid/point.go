@@ -0,0 +1,114 @@
+package grid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Point is a position in a grid. Rows count down from the top of the grid and columns count right from the left,
+// both starting at 0.
+type Point struct {
+	Row int
+	Col int
+}
+
+// Add returns the point one step away from p in the provided direction.
+func (p Point) Add(d Direction) Point {
+	return Point{Row: p.Row + d.DRow, Col: p.Col + d.DCol}
+}
+
+// Step returns the point n steps away from p in the provided direction.
+func (p Point) Step(d Direction, n int) Point {
+	return Point{Row: p.Row + n*d.DRow, Col: p.Col + n*d.DCol}
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%d,%d)", p.Row, p.Col)
+}
+
+// Direction is the vector between a point and one of its neighbours.
+type Direction struct {
+	DRow int
+	DCol int
+}
+
+// The eight directions to a point's neighbours
+var (
+	Up        = Direction{DRow: -1, DCol: 0}
+	UpRight   = Direction{DRow: -1, DCol: 1}
+	Right     = Direction{DRow: 0, DCol: 1}
+	DownRight = Direction{DRow: 1, DCol: 1}
+	Down      = Direction{DRow: 1, DCol: 0}
+	DownLeft  = Direction{DRow: 1, DCol: -1}
+	Left      = Direction{DRow: 0, DCol: -1}
+	UpLeft    = Direction{DRow: -1, DCol: -1}
+)
+
+// Orthogonal lists the four directions that share an edge with a point, clockwise starting from Up.
+var Orthogonal = []Direction{Up, Right, Down, Left}
+
+// Diagonal lists the four directions that only share a corner with a point, clockwise starting from UpRight.
+var Diagonal = []Direction{UpRight, DownRight, DownLeft, UpLeft}
+
+// All lists all eight directions around a point, clockwise starting from Up.
+var All = []Direction{Up, UpRight, Right, DownRight, Down, DownLeft, Left, UpLeft}
+
+var directionNames = map[Direction]string{
+	Up:        "up",
+	UpRight:   "up-right",
+	Right:     "right",
+	DownRight: "down-right",
+	Down:      "down",
+	DownLeft:  "down-left",
+	Left:      "left",
+	UpLeft:    "up-left",
+}
+
+// Opposite returns the direction pointing the other way.
+func (d Direction) Opposite() Direction {
+	return Direction{DRow: -d.DRow, DCol: -d.DCol}
+}
+
+// RotateClockwise returns the direction turned 90 degrees clockwise.
+func (d Direction) RotateClockwise() Direction {
+	return Direction{DRow: d.DCol, DCol: -d.DRow}
+}
+
+// RotateCounterClockwise returns the direction turned 90 degrees counter-clockwise.
+func (d Direction) RotateCounterClockwise() Direction {
+	return Direction{DRow: -d.DCol, DCol: d.DRow}
+}
+
+// Rotate45 turns one of the eight directions in All by the provided number of 45 degree steps, clockwise for
+// positive steps and counter-clockwise for negative ones. It panics for any other direction.
+func (d Direction) Rotate45(steps int) Direction {
+	for i, direction := range All {
+		if direction == d {
+			index := (i + steps) % len(All)
+			if index < 0 {
+				index += len(All)
+			}
+			return All[index]
+		}
+	}
+
+	panic(fmt.Sprintf("unable to rotate %s by 45 degrees, it is not a unit direction", d))
+}
+
+func (d Direction) String() string {
+	if name, ok := directionNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("(%d,%d)", d.DRow, d.DCol)
+}
+
+// ParseDirection converts the name of one of the eight directions, as returned by String, to a Direction.
+func ParseDirection(name string) (Direction, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for direction, directionName := range directionNames {
+		if directionName == name {
+			return direction, nil
+		}
+	}
+	return Direction{}, fmt.Errorf("unknown direction %q, expected one of up, up-right, right, down-right, down, down-left, left or up-left", name)
+}
@@ -0,0 +1,257 @@
+package wordsearch
+
+// The size of the glyphs in font5x7, in pixels
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// font5x7 is a tiny bitmap font for drawing letters into images without needing a font file. Each glyph is
+// drawn as 7 lines of 5 pixels, where "#" is a pixel that is set.
+var font5x7 = map[rune][glyphHeight]string{
+	'A': {
+		" ### ",
+		"#   #",
+		"#   #",
+		"#####",
+		"#   #",
+		"#   #",
+		"#   #",
+	},
+	'B': {
+		"#### ",
+		"#   #",
+		"#   #",
+		"#### ",
+		"#   #",
+		"#   #",
+		"#### ",
+	},
+	'C': {
+		" ### ",
+		"#   #",
+		"#    ",
+		"#    ",
+		"#    ",
+		"#   #",
+		" ### ",
+	},
+	'D': {
+		"#### ",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#### ",
+	},
+	'E': {
+		"#####",
+		"#    ",
+		"#    ",
+		"#### ",
+		"#    ",
+		"#    ",
+		"#####",
+	},
+	'F': {
+		"#####",
+		"#    ",
+		"#    ",
+		"#### ",
+		"#    ",
+		"#    ",
+		"#    ",
+	},
+	'G': {
+		" ### ",
+		"#   #",
+		"#    ",
+		"# ###",
+		"#   #",
+		"#   #",
+		" ### ",
+	},
+	'H': {
+		"#   #",
+		"#   #",
+		"#   #",
+		"#####",
+		"#   #",
+		"#   #",
+		"#   #",
+	},
+	'I': {
+		" ### ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		" ### ",
+	},
+	'J': {
+		"  ###",
+		"   # ",
+		"   # ",
+		"   # ",
+		"   # ",
+		"#  # ",
+		" ##  ",
+	},
+	'K': {
+		"#   #",
+		"#  # ",
+		"# #  ",
+		"##   ",
+		"# #  ",
+		"#  # ",
+		"#   #",
+	},
+	'L': {
+		"#    ",
+		"#    ",
+		"#    ",
+		"#    ",
+		"#    ",
+		"#    ",
+		"#####",
+	},
+	'M': {
+		"#   #",
+		"## ##",
+		"# # #",
+		"# # #",
+		"#   #",
+		"#   #",
+		"#   #",
+	},
+	'N': {
+		"#   #",
+		"##  #",
+		"# # #",
+		"#  ##",
+		"#   #",
+		"#   #",
+		"#   #",
+	},
+	'O': {
+		" ### ",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		" ### ",
+	},
+	'P': {
+		"#### ",
+		"#   #",
+		"#   #",
+		"#### ",
+		"#    ",
+		"#    ",
+		"#    ",
+	},
+	'Q': {
+		" ### ",
+		"#   #",
+		"#   #",
+		"#   #",
+		"# # #",
+		"#  # ",
+		" ## #",
+	},
+	'R': {
+		"#### ",
+		"#   #",
+		"#   #",
+		"#### ",
+		"# #  ",
+		"#  # ",
+		"#   #",
+	},
+	'S': {
+		" ####",
+		"#    ",
+		"#    ",
+		" ### ",
+		"    #",
+		"    #",
+		"#### ",
+	},
+	'T': {
+		"#####",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+	},
+	'U': {
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		" ### ",
+	},
+	'V': {
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		"#   #",
+		" # # ",
+		"  #  ",
+	},
+	'W': {
+		"#   #",
+		"#   #",
+		"#   #",
+		"# # #",
+		"# # #",
+		"# # #",
+		" # # ",
+	},
+	'X': {
+		"#   #",
+		"#   #",
+		" # # ",
+		"  #  ",
+		" # # ",
+		"#   #",
+		"#   #",
+	},
+	'Y': {
+		"#   #",
+		"#   #",
+		" # # ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+		"  #  ",
+	},
+	'Z': {
+		"#####",
+		"    #",
+		"   # ",
+		"  #  ",
+		" #   ",
+		"#    ",
+		"#####",
+	},
+}
+
+// missingGlyph is drawn for characters that font5x7 has no glyph for.
+var missingGlyph = [glyphHeight]string{
+	"#####",
+	"#   #",
+	"#   #",
+	"#   #",
+	"#   #",
+	"#   #",
+	"#####",
+}
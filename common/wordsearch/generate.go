@@ -0,0 +1,242 @@
+package wordsearch
+
+import (
+	"fmt"
+	"math/rand"
+
+	"common/grid"
+)
+
+// fillerLetters fill every cell that isn't part of a planted word. None of them are an X, M, A or S, so filler can
+// never be part of an XMAS or an X-MAS, whatever is planted around it.
+const fillerLetters = "BCDEFGHIJKLNOPQRTUVWYZ"
+
+// maxPlantAttempts is the number of random spots tried for each word or cross before the grid is given up on as
+// too crowded.
+const maxPlantAttempts = 10000
+
+// xmasWord is the word planted by Generate, and searched for in part 1 of day 4.
+var xmasWord = []byte("XMAS")
+
+// GeneratorOptions controls the size and content of the grid built by Generate.
+type GeneratorOptions struct {
+	// Seed makes the output reproducible, the same options always produce the same grid
+	Seed int64
+	// Rows and Cols are the size of the grid
+	Rows int
+	Cols int
+	// Words is the number of XMAS words to plant
+	Words int
+	// Crosses is the number of X-MAS crosses to plant, two MAS words crossing on their A
+	Crosses int
+	// Directions lists the directions XMAS words may be planted in, all eight of them when it is empty
+	Directions []grid.Direction
+}
+
+// Generated is a grid built by Generate, along with the exact answers to both parts of day 4 for it.
+type Generated struct {
+	Grid *grid.Grid[byte]
+	// Words is the number of times XMAS appears in the grid, in any direction
+	Words int
+	// Crosses is the number of X-MAS crosses in the grid
+	Crosses int
+}
+
+// generator holds the state of a grid while words are planted in it.
+type generator struct {
+	rng *rand.Rand
+	g   *grid.Grid[byte]
+	// planted marks the cells holding a letter of a planted word, which can only be shared by another word
+	// needing the same letter there
+	planted *grid.Grid[bool]
+}
+
+// Generate builds a random word search grid in the style of the day 4 input, with the requested number of XMAS
+// words and X-MAS crosses planted in it. Each word is only kept if it adds exactly one match to the grid, without
+// breaking or accidentally completing any other, so the answers are known exactly without searching the result.
+func Generate(opts GeneratorOptions) (*Generated, error) {
+	if opts.Rows < 1 || opts.Cols < 1 {
+		return nil, fmt.Errorf("grid size must be positive, got %dx%d", opts.Rows, opts.Cols)
+	}
+	if opts.Words < 0 || opts.Crosses < 0 {
+		return nil, fmt.Errorf("number of words and crosses can't be negative, got %d and %d", opts.Words, opts.Crosses)
+	}
+
+	directions := opts.Directions
+	if len(directions) == 0 {
+		directions = grid.All
+	}
+	for _, dir := range directions {
+		if dir.DRow < -1 || dir.DRow > 1 || dir.DCol < -1 || dir.DCol > 1 || dir == (grid.Direction{}) {
+			return nil, fmt.Errorf("unable to plant words in direction %s, it is not one of the eight directions", dir)
+		}
+	}
+
+	gen := &generator{
+		rng:     rand.New(rand.NewSource(opts.Seed)),
+		g:       grid.New[byte](opts.Rows, opts.Cols),
+		planted: grid.New[bool](opts.Rows, opts.Cols),
+	}
+
+	for row := 0; row < opts.Rows; row++ {
+		cells := gen.g.Row(row)
+		for col := range cells {
+			cells[col] = fillerLetters[gen.rng.Intn(len(fillerLetters))]
+		}
+	}
+
+	// Plant the words and crosses in a random order, so neither kind always gets the first pick of the grid
+	crosses := make([]bool, 0, opts.Words+opts.Crosses)
+	for i := 0; i < opts.Words; i++ {
+		crosses = append(crosses, false)
+	}
+	for i := 0; i < opts.Crosses; i++ {
+		crosses = append(crosses, true)
+	}
+	gen.rng.Shuffle(len(crosses), func(i, j int) {
+		crosses[i], crosses[j] = crosses[j], crosses[i]
+	})
+
+	for i, cross := range crosses {
+		planted := false
+		for attempt := 0; attempt < maxPlantAttempts && !planted; attempt++ {
+			if cross {
+				planted = gen.plantCross()
+			} else {
+				planted = gen.plantWord(directions)
+			}
+		}
+
+		if !planted {
+			return nil, fmt.Errorf("unable to plant %d words and %d crosses in a %dx%d grid, it became too crowded after %d",
+				opts.Words, opts.Crosses, opts.Rows, opts.Cols, i)
+		}
+	}
+
+	return &Generated{Grid: gen.g, Words: opts.Words, Crosses: opts.Crosses}, nil
+}
+
+// plantWord tries to plant XMAS at a random spot in one of the directions, and reports whether it was planted.
+func (gen *generator) plantWord(directions []grid.Direction) bool {
+	dir := directions[gen.rng.Intn(len(directions))]
+	start := grid.Point{Row: gen.rng.Intn(gen.g.Rows()), Col: gen.rng.Intn(gen.g.Cols())}
+
+	cells := make([]grid.Point, len(xmasWord))
+	for i := range cells {
+		cells[i] = start.Step(dir, i)
+	}
+	return gen.plant(cells, xmasWord, 1, 0)
+}
+
+// plantCross tries to plant an X-MAS centered on a random spot, with each MAS reading a random way along its
+// diagonal, and reports whether it was planted.
+func (gen *generator) plantCross() bool {
+	if gen.g.Rows() < 3 || gen.g.Cols() < 3 {
+		return false
+	}
+
+	center := grid.Point{Row: 1 + gen.rng.Intn(gen.g.Rows()-2), Col: 1 + gen.rng.Intn(gen.g.Cols()-2)}
+	cells := []grid.Point{center}
+	letters := []byte{'A'}
+	for _, dir := range []grid.Direction{grid.UpLeft, grid.UpRight} {
+		if gen.rng.Intn(2) == 0 {
+			dir = dir.Opposite()
+		}
+		cells = append(cells, center.Add(dir), center.Add(dir.Opposite()))
+		letters = append(letters, 'M', 'S')
+	}
+	return gen.plant(cells, letters, 0, 1)
+}
+
+// plant writes the letters into the cells, as long as that adds exactly the expected number of words and crosses
+// to the grid. Otherwise the grid is left as it was. It reports whether the letters were planted.
+func (gen *generator) plant(cells []grid.Point, letters []byte, words int, crosses int) bool {
+	for i, p := range cells {
+		if !gen.g.InBounds(p) || (gen.planted.At(p) && gen.g.At(p) != letters[i]) {
+			return false
+		}
+	}
+
+	// Any match that is made or broken must cover one of the cells being changed, so only the matches around them
+	// need to be counted before and after
+	wordsBefore, crossesBefore := gen.countAround(cells)
+
+	previous := make([]byte, len(cells))
+	for i, p := range cells {
+		previous[i] = gen.g.At(p)
+		gen.g.Set(p, letters[i])
+	}
+
+	wordsAfter, crossesAfter := gen.countAround(cells)
+	if wordsAfter-wordsBefore != words || crossesAfter-crossesBefore != crosses {
+		for i, p := range cells {
+			gen.g.Set(p, previous[i])
+		}
+		return false
+	}
+
+	for _, p := range cells {
+		gen.planted.Set(p, true)
+	}
+	return true
+}
+
+// countAround counts the XMAS words and X-MAS crosses that cover at least one of the cells.
+func (gen *generator) countAround(cells []grid.Point) (int, int) {
+	type word struct {
+		start grid.Point
+		dir   grid.Direction
+	}
+	words := make(map[word]bool)
+	crosses := make(map[grid.Point]bool)
+
+	for _, p := range cells {
+		for _, dir := range grid.All {
+			for i := range xmasWord {
+				start := p.Step(dir, -i)
+				if isWordAt(gen.g, start, dir) {
+					words[word{start: start, dir: dir}] = true
+				}
+			}
+		}
+
+		// A cross covers its center and the four cells diagonal to it
+		centers := []grid.Point{p}
+		for _, dir := range grid.Diagonal {
+			centers = append(centers, p.Add(dir))
+		}
+		for _, center := range centers {
+			if isCrossAt(gen.g, center) {
+				crosses[center] = true
+			}
+		}
+	}
+
+	return len(words), len(crosses)
+}
+
+// isWordAt reports whether XMAS is read from the start point in the direction.
+func isWordAt(g *grid.Grid[byte], start grid.Point, dir grid.Direction) bool {
+	for i, letter := range xmasWord {
+		if cell, ok := g.Get(start.Step(dir, i)); !ok || cell != letter {
+			return false
+		}
+	}
+	return true
+}
+
+// isCrossAt reports whether the point is the A in the middle of an X-MAS.
+func isCrossAt(g *grid.Grid[byte], center grid.Point) bool {
+	if cell, ok := g.Get(center); !ok || cell != 'A' {
+		return false
+	}
+
+	for _, dir := range []grid.Direction{grid.UpLeft, grid.UpRight} {
+		first, _ := g.Get(center.Add(dir))
+		second, _ := g.Get(center.Add(dir.Opposite()))
+		if !(first == 'M' && second == 'S') && !(first == 'S' && second == 'M') {
+			return false
+		}
+	}
+	return true
+}
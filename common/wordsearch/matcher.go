@@ -0,0 +1,290 @@
+// Package wordsearch finds words hidden in grids of letters, in any of the eight directions, the way they are
+// hidden in the day 4 puzzles.
+package wordsearch
+
+import (
+	"fmt"
+
+	"common/grid"
+)
+
+// Match is a single occurrence of a word in a grid.
+type Match struct {
+	// Word is the index of the word in the list the Matcher was built from
+	Word int
+	// Start is the point holding the first letter of the word
+	Start grid.Point
+	// Dir is the direction the word is read in, starting from Start
+	Dir grid.Direction
+	// Length is the number of cells the word covers
+	Length int
+	// Layer is the layer holding the first letter in a layered grid, and is always 0 in a flat grid
+	Layer int
+	// DLayer is the number of layers the word moves through from one letter to the next in a layered grid,
+	// and is always 0 in a flat grid
+	DLayer int
+}
+
+// Cells returns the points covered by the match within their layers, from the first letter to the last.
+// Matches found on a torus run straight off the edge of the grid, so pass each point through grid.Grid.Wrap
+// to bring it back onto the grid.
+func (m Match) Cells() []grid.Point {
+	cells := make([]grid.Point, m.Length)
+	for i := range cells {
+		cells[i] = m.Start.Step(m.Dir, i)
+	}
+	return cells
+}
+
+// Start3 returns the point holding the first letter of a match in a layered grid.
+func (m Match) Start3() grid.Point3 {
+	return grid.Point3{Layer: m.Layer, Row: m.Start.Row, Col: m.Start.Col}
+}
+
+// Dir3 returns the direction of a match in a layered grid.
+func (m Match) Dir3() grid.Direction3 {
+	return grid.Direction3{DLayer: m.DLayer, DRow: m.Dir.DRow, DCol: m.Dir.DCol}
+}
+
+// Cells3 returns the points covered by a match in a layered grid, from the first letter to the last.
+func (m Match) Cells3() []grid.Point3 {
+	cells := make([]grid.Point3, m.Length)
+	for i := range cells {
+		cells[i] = m.Start3().Step(m.Dir3(), i)
+	}
+	return cells
+}
+
+// Matcher is an Aho-Corasick automaton built from a list of words. It finds every occurrence of every word in a
+// line of cells in a single pass, rather than needing a separate scan of the grid for each word.
+type Matcher[T comparable] struct {
+	words   [][]T
+	longest int
+	nodes   []node[T]
+}
+
+// node is a state of the automaton, which represents the prefix of one or more words matched so far.
+type node[T comparable] struct {
+	next map[T]int
+	// fail is the state for the longest proper suffix of this prefix that is also the prefix of a word
+	fail int
+	// output lists the words ending at this state, including those reached through the fail links
+	output []int
+}
+
+// NewMatcher builds the automaton for the provided words. Words may not be empty.
+func NewMatcher[T comparable](words [][]T) (*Matcher[T], error) {
+	m := &Matcher[T]{words: words, nodes: []node[T]{{next: make(map[T]int)}}}
+
+	// First build a trie out of all the words
+	for i, word := range words {
+		if len(word) == 0 {
+			return nil, fmt.Errorf("word %d is empty", i)
+		}
+		if len(word) > m.longest {
+			m.longest = len(word)
+		}
+
+		state := 0
+		for _, letter := range word {
+			next, ok := m.nodes[state].next[letter]
+			if !ok {
+				next = len(m.nodes)
+				m.nodes = append(m.nodes, node[T]{next: make(map[T]int)})
+				m.nodes[state].next[letter] = next
+			}
+			state = next
+		}
+		m.nodes[state].output = append(m.nodes[state].output, i)
+	}
+
+	// Then fill in the fail links breadth first, so the links of shorter prefixes are always ready before
+	// they are needed by longer ones
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for letter, child := range m.nodes[state].next {
+			m.nodes[child].fail = m.step(m.nodes[state].fail, letter)
+			fail := m.nodes[child].fail
+			m.nodes[child].output = append(m.nodes[child].output, m.nodes[fail].output...)
+			queue = append(queue, child)
+		}
+	}
+
+	return m, nil
+}
+
+// NewByteMatcher builds a matcher for plain ASCII words, to be used with a grid of bytes.
+func NewByteMatcher(words ...string) (*Matcher[byte], error) {
+	byteWords := make([][]byte, len(words))
+	for i, word := range words {
+		byteWords[i] = []byte(word)
+	}
+	return NewMatcher(byteWords)
+}
+
+// Words returns the words the matcher was built from.
+func (m *Matcher[T]) Words() [][]T {
+	return m.words
+}
+
+// step follows the transition for the letter out of the state, falling back through the fail links
+// when the state has no transition for it.
+func (m *Matcher[T]) step(state int, letter T) int {
+	for {
+		if next, ok := m.nodes[state].next[letter]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = m.nodes[state].fail
+	}
+}
+
+// scan feeds a line of cells through the automaton, where cell returns each cell in turn until it reports that
+// the line has ended. found is called with the word and the index of its first cell for every word that ends
+// somewhere on the line.
+func (m *Matcher[T]) scan(cell func(i int) (T, bool), found func(word int, start int)) {
+	state := 0
+	for i := 0; ; i++ {
+		letter, ok := cell(i)
+		if !ok {
+			return
+		}
+
+		state = m.step(state, letter)
+		for _, word := range m.nodes[state].output {
+			found(word, i-len(m.words[word])+1)
+		}
+	}
+}
+
+// singleLetterDir is the only direction one-letter words are reported in. A single letter has no direction, so
+// reporting it for every direction would find each occurrence eight times over, or 26 in a layered grid.
+var singleLetterDir = grid.Right
+
+// reportedIn reports whether a match of the word read in the direction is reported, which is always true unless
+// the word is a single letter.
+func (m *Matcher[T]) reportedIn(word int, dir grid.Direction, dLayer int) bool {
+	return len(m.words[word]) > 1 || (dir == singleLetterDir && dLayer == 0)
+}
+
+// FindAll streams every row, column and diagonal of the grid through the automaton, once in each direction,
+// and returns every occurrence of every word. The edges of the grid are walls that words can't cross.
+// A word that reads the same in two directions, like a palindrome, is reported once for each direction, but a
+// one-letter word is only reported once, reading to the right.
+func (m *Matcher[T]) FindAll(g *grid.Grid[T]) []Match {
+	matches := make([]Match, 0)
+	for _, dir := range grid.All {
+		back := dir.Opposite()
+		g.ForEach(func(start grid.Point, _ T) {
+			// A line starts at every point whose predecessor in the direction is off the edge of the grid
+			if g.InBounds(start.Add(back)) {
+				return
+			}
+
+			m.scan(func(i int) (T, bool) {
+				return g.Get(start.Step(dir, i))
+			}, func(word int, first int) {
+				if !m.reportedIn(word, dir, 0) {
+					return
+				}
+				matches = append(matches, Match{
+					Word:   word,
+					Start:  start.Step(dir, first),
+					Dir:    dir,
+					Length: len(m.words[word]),
+				})
+			})
+		})
+	}
+	return matches
+}
+
+// FindAllTorus treats the grid as a torus, where a word that runs off one edge carries on from the opposite edge.
+// Every line through the grid then becomes a loop, and words may start anywhere on a loop. A word can't be longer
+// than its loop though, so no cell is used twice by the same match.
+func (m *Matcher[T]) FindAllTorus(g *grid.Grid[T]) []Match {
+	matches := make([]Match, 0)
+	for _, dir := range grid.All {
+		// Each point is on exactly one loop per direction, so track which have been seen to visit each loop once
+		seen := grid.New[bool](g.Rows(), g.Cols())
+		g.ForEach(func(start grid.Point, _ T) {
+			if seen.At(start) {
+				return
+			}
+
+			loop := make([]grid.Point, 0)
+			for p := start; !seen.At(p); p = g.Wrap(p.Add(dir)) {
+				seen.Set(p, true)
+				loop = append(loop, p)
+			}
+
+			// Go around the loop a second time, just far enough to finish the words that cross its starting point
+			overlap := m.longest
+			if overlap > len(loop) {
+				overlap = len(loop)
+			}
+			length := len(loop) + overlap - 1
+
+			m.scan(func(i int) (T, bool) {
+				if i >= length {
+					var zero T
+					return zero, false
+				}
+				return g.At(loop[i%len(loop)]), true
+			}, func(word int, first int) {
+				// Words starting on the second time around were already found the first time
+				if first >= len(loop) || len(m.words[word]) > len(loop) || !m.reportedIn(word, dir, 0) {
+					return
+				}
+				matches = append(matches, Match{Word: word, Start: loop[first], Dir: dir, Length: len(m.words[word])})
+			})
+		})
+	}
+	return matches
+}
+
+// FindAll3D searches a layered grid in all 26 directions, including the directions that move between layers.
+// Like FindAll, the edges of the grid are walls that words can't cross.
+func (m *Matcher[T]) FindAll3D(g *grid.Grid3[T]) []Match {
+	matches := make([]Match, 0)
+	for _, dir := range grid.All3 {
+		back := dir.Opposite()
+		for layer := 0; layer < g.Layers(); layer++ {
+			for row := 0; row < g.Rows(); row++ {
+				for col := 0; col < g.Cols(); col++ {
+					start := grid.Point3{Layer: layer, Row: row, Col: col}
+					if g.InBounds(start.Add(back)) {
+						continue
+					}
+
+					m.scan(func(i int) (T, bool) {
+						return g.Get(start.Step(dir, i))
+					}, func(word int, first int) {
+						if !m.reportedIn(word, dir.Flat(), dir.DLayer) {
+							return
+						}
+						p := start.Step(dir, first)
+						matches = append(matches, Match{
+							Word:   word,
+							Start:  grid.Point{Row: p.Row, Col: p.Col},
+							Dir:    dir.Flat(),
+							Length: len(m.words[word]),
+							Layer:  p.Layer,
+							DLayer: dir.DLayer,
+						})
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,94 @@
+package wordsearch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"common/grid"
+)
+
+func TestFindAll3D(t *testing.T) {
+	// CAT only reads diagonally down and to the right while moving up a layer, through the centre of the cube
+	layers := "C..\n...\n...\n\n...\n.A.\n...\n\n...\n...\n..T\n"
+	g, err := grid.LoadLayers(strings.NewReader(layers), grid.Bytes, grid.LoadOptions[byte]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewByteMatcher("CAT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := matcher.FindAll3D(g)
+	if len(matches) != 1 {
+		t.Fatalf("expected a single match, got %v", matches)
+	}
+	m := matches[0]
+	if m.Layer != 0 || m.DLayer != 1 || m.Start != (grid.Point{Row: 0, Col: 0}) || m.Dir != grid.DownRight {
+		t.Errorf("expected the match to start at the top left of layer 0 and run down-right up a layer, got %+v", m)
+	}
+	want := []grid.Point3{{Layer: 0, Row: 0, Col: 0}, {Layer: 1, Row: 1, Col: 1}, {Layer: 2, Row: 2, Col: 2}}
+	if got := m.Cells3(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the match to cover %v, got %v", want, got)
+	}
+
+	// None of the layers has the word on its own
+	for layer := 0; layer < g.Layers(); layer++ {
+		flat := grid.New[byte](g.Rows(), g.Cols())
+		flat.ForEach(func(p grid.Point, _ byte) {
+			flat.Set(p, g.At(grid.Point3{Layer: layer, Row: p.Row, Col: p.Col}))
+		})
+		if found := matcher.FindAll(flat); len(found) != 0 {
+			t.Errorf("expected nothing in layer %d alone, got %v", layer, found)
+		}
+	}
+}
+
+func TestFindAllTorus(t *testing.T) {
+	// CAT starts in the bottom right corner and reads down-right, wrapping across the bottom and right edges at once
+	g, err := grid.Load(strings.NewReader("A...\n.T..\n...C\n"), grid.Bytes, grid.LoadOptions[byte]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewByteMatcher("CAT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found := matcher.FindAll(g); len(found) != 0 {
+		t.Errorf("expected nothing on the flat grid, got %v", found)
+	}
+
+	matches := matcher.FindAllTorus(g)
+	if len(matches) != 1 {
+		t.Fatalf("expected a single match, got %v", matches)
+	}
+	m := matches[0]
+	if m.Start != (grid.Point{Row: 2, Col: 3}) || m.Dir != grid.DownRight || m.Length != 3 {
+		t.Errorf("expected the match to start at the bottom right and run down-right, got %+v", m)
+	}
+
+	cells := make([]grid.Point, 0)
+	for _, p := range m.Cells() {
+		cells = append(cells, g.Wrap(p))
+	}
+	if want := []grid.Point{{Row: 2, Col: 3}, {Row: 0, Col: 0}, {Row: 1, Col: 1}}; !reflect.DeepEqual(cells, want) {
+		t.Errorf("expected the match to cover %v, got %v", want, cells)
+	}
+
+	// A word longer than its loop would have to reuse cells, so it isn't found
+	matcher, err = NewByteMatcher("ABAB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = grid.Load(strings.NewReader("AB\n"), grid.Bytes, grid.LoadOptions[byte]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := matcher.FindAllTorus(g); len(found) != 0 {
+		t.Errorf("expected ABAB not to fit around a loop of 2 cells, got %v", found)
+	}
+}
@@ -0,0 +1,240 @@
+package wordsearch
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"common/grid"
+)
+
+// Highlights converts matches into the groups of cells to highlight when rendering a grid, one group per match.
+// Matches found on a torus are wrapped back onto the grid.
+func Highlights(g *grid.Grid[string], matches []Match) [][]grid.Point {
+	groups := make([][]grid.Point, len(matches))
+	for i, match := range matches {
+		cells := match.Cells()
+		for j, cell := range cells {
+			cells[j] = g.Wrap(cell)
+		}
+		groups[i] = cells
+	}
+	return groups
+}
+
+// highlightColours assigns every highlighted cell the index of the first group it belongs to, which picks its colour
+// from the palette. Cells that aren't in any group are left out, and get dimmed.
+func highlightColours(highlights [][]grid.Point) map[grid.Point]int {
+	colours := make(map[grid.Point]int)
+	for i, group := range highlights {
+		for _, p := range group {
+			if _, ok := colours[p]; !ok {
+				colours[p] = i
+			}
+		}
+	}
+	return colours
+}
+
+// Render draws the grid in the named format, which is ansi, svg or png.
+func Render(w io.Writer, format string, g *grid.Grid[string], highlights [][]grid.Point) error {
+	switch format {
+	case "ansi":
+		return RenderANSI(w, g, highlights)
+	case "svg":
+		return RenderSVG(w, g, highlights)
+	case "png":
+		return RenderPNG(w, g, highlights)
+	}
+	return fmt.Errorf("unknown render format %q, expected ansi, svg or png", format)
+}
+
+// RenderFile draws the grid in the named format, like Render, into a new file. An empty filename writes to
+// standard output instead.
+func RenderFile(filename string, format string, g *grid.Grid[string], highlights [][]grid.Point) error {
+	if filename == "" {
+		return Render(os.Stdout, format, g, highlights)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := Render(file, format, g, highlights); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to render grid to %s due to: %w", filename, err)
+	}
+	return file.Close()
+}
+
+// ansiPalette holds the foreground colour codes used for highlighted cells in a terminal.
+var ansiPalette = []int{31, 32, 33, 34, 35, 36}
+
+// RenderANSI draws the grid to a terminal using ANSI escape codes. Highlighted cells are bold and coloured by the
+// group they belong to, and every other cell is dimmed.
+func RenderANSI(w io.Writer, g *grid.Grid[string], highlights [][]grid.Point) error {
+	colours := highlightColours(highlights)
+	out := bufio.NewWriter(w)
+
+	for row := 0; row < g.Rows(); row++ {
+		cells := g.Row(row)
+		for col, cell := range cells {
+			if colour, ok := colours[grid.Point{Row: row, Col: col}]; ok {
+				fmt.Fprintf(out, "\x1b[1;%dm%s\x1b[0m", ansiPalette[colour%len(ansiPalette)], displayCell(cell))
+			} else {
+				fmt.Fprintf(out, "\x1b[2m%s\x1b[0m", displayCell(cell))
+			}
+		}
+		out.WriteString("\n")
+	}
+
+	return out.Flush()
+}
+
+// palette holds the background colours of highlighted cells in images.
+var palette = []color.RGBA{
+	{R: 0xf4, G: 0xa2, B: 0x61, A: 0xff},
+	{R: 0x8a, G: 0xc9, B: 0x26, A: 0xff},
+	{R: 0x4c, G: 0xc9, B: 0xf0, A: 0xff},
+	{R: 0xf7, G: 0x7f, B: 0xbe, A: 0xff},
+	{R: 0xff, G: 0xd1, B: 0x66, A: 0xff},
+	{R: 0xb3, G: 0x9d, B: 0xdb, A: 0xff},
+}
+
+var (
+	background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	dimmedText = color.RGBA{R: 0xc8, G: 0xc8, B: 0xc8, A: 0xff}
+	strongText = color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 0xff}
+)
+
+// svgCellSize is the width and height of each cell in an SVG, in pixels.
+const svgCellSize = 16
+
+// RenderSVG draws the grid as a standalone SVG image. Highlighted cells get a background coloured by the group
+// they belong to, and the letters of every other cell are dimmed.
+func RenderSVG(w io.Writer, g *grid.Grid[string], highlights [][]grid.Point) error {
+	colours := highlightColours(highlights)
+	out := bufio.NewWriter(w)
+
+	width, height := g.Cols()*svgCellSize, g.Rows()*svgCellSize
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(out, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, hexColour(background))
+	fmt.Fprintf(out, `<g font-family="monospace" font-size="%d" text-anchor="middle" dominant-baseline="central">`+"\n",
+		svgCellSize*3/4)
+
+	for row := 0; row < g.Rows(); row++ {
+		cells := g.Row(row)
+		for col, cell := range cells {
+			x, y := col*svgCellSize, row*svgCellSize
+			text := dimmedText
+			weight := "normal"
+
+			if colour, ok := colours[grid.Point{Row: row, Col: col}]; ok {
+				fmt.Fprintf(out, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x, y, svgCellSize, svgCellSize, hexColour(palette[colour%len(palette)]))
+				text = strongText
+				weight = "bold"
+			}
+
+			fmt.Fprintf(out, `<text x="%d" y="%d" fill="%s" font-weight="%s">%s</text>`+"\n",
+				x+svgCellSize/2, y+svgCellSize/2, hexColour(text), weight, html.EscapeString(displayCell(cell)))
+		}
+	}
+
+	out.WriteString("</g>\n</svg>\n")
+	return out.Flush()
+}
+
+func hexColour(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// PNG cells are drawn with the glyphs from font5x7, scaled up by pngScale, and with a margin around each glyph.
+const (
+	pngScale      = 2
+	pngMargin     = 2
+	pngCellWidth  = (glyphWidth + 2*pngMargin) * pngScale
+	pngCellHeight = (glyphHeight + 2*pngMargin) * pngScale
+)
+
+// RenderPNG draws the grid as a PNG image using a built in bitmap font, which only has glyphs for the letters
+// A to Z. Every other character is drawn as a hollow box. Highlighted cells get a background coloured by the group
+// they belong to, and the letters of every other cell are dimmed.
+func RenderPNG(w io.Writer, g *grid.Grid[string], highlights [][]grid.Point) error {
+	colours := highlightColours(highlights)
+	img := image.NewRGBA(image.Rect(0, 0, g.Cols()*pngCellWidth, g.Rows()*pngCellHeight))
+
+	for row := 0; row < g.Rows(); row++ {
+		cells := g.Row(row)
+		for col, cell := range cells {
+			x, y := col*pngCellWidth, row*pngCellHeight
+			fill := background
+			text := dimmedText
+
+			if colour, ok := colours[grid.Point{Row: row, Col: col}]; ok {
+				fill = palette[colour%len(palette)]
+				text = strongText
+			}
+
+			fillRect(img, image.Rect(x, y, x+pngCellWidth, y+pngCellHeight), fill)
+			drawGlyph(img, x+pngMargin*pngScale, y+pngMargin*pngScale, glyphFor(cell), text)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawGlyph draws a glyph with its top left corner at x, y, scaled up by pngScale.
+func drawGlyph(img *image.RGBA, x int, y int, glyph [glyphHeight]string, c color.RGBA) {
+	for gy, line := range glyph {
+		for gx, pixel := range line {
+			if pixel != '#' {
+				continue
+			}
+			px, py := x+gx*pngScale, y+gy*pngScale
+			fillRect(img, image.Rect(px, py, px+pngScale, py+pngScale), c)
+		}
+	}
+}
+
+// glyphFor returns the glyph for the first character of the cell, or a hollow box if there isn't one.
+// Blank cells have no glyph at all.
+func glyphFor(cell string) [glyphHeight]string {
+	if strings.TrimSpace(cell) == "" {
+		return [glyphHeight]string{}
+	}
+
+	for _, r := range cell {
+		if glyph, ok := font5x7[unicode.ToUpper(r)]; ok {
+			return glyph
+		}
+		break
+	}
+	return missingGlyph
+}
+
+// displayCell returns the text to show for a cell. Blank cells of ragged grids are shown as a space, so the
+// columns stay lined up.
+func displayCell(cell string) string {
+	if strings.TrimSpace(cell) == "" {
+		return " "
+	}
+	return cell
+}
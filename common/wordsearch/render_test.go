@@ -0,0 +1,155 @@
+package wordsearch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"common/grid"
+)
+
+// renderTestGrid returns a grid with XMAS along its first row, and characters that need escaping or have no glyph
+// in the font on its second, along with the match of XMAS as the only highlight.
+func renderTestGrid(t *testing.T) (*grid.Grid[string], [][]grid.Point) {
+	t.Helper()
+
+	g, err := grid.FromRows([][]string{grid.Characters("XMAS"), grid.Characters("<&?a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	highlights := Highlights(g, []Match{{Start: grid.Point{Row: 0, Col: 0}, Dir: grid.Right, Length: 4}})
+	return g, highlights
+}
+
+func TestRenderANSI(t *testing.T) {
+	g, highlights := renderTestGrid(t)
+
+	var out strings.Builder
+	if err := Render(&out, "ansi", g, highlights); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per row, got %q", out.String())
+	}
+	if want := "\x1b[1;31mX\x1b[0m\x1b[1;31mM\x1b[0m\x1b[1;31mA\x1b[0m\x1b[1;31mS\x1b[0m"; lines[0] != want {
+		t.Errorf("expected the matched row to be bold and coloured, got %q", lines[0])
+	}
+	if want := "\x1b[2m<\x1b[0m\x1b[2m&\x1b[0m\x1b[2m?\x1b[0m\x1b[2ma\x1b[0m"; lines[1] != want {
+		t.Errorf("expected the other row to be dimmed, got %q", lines[1])
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	g, highlights := renderTestGrid(t)
+
+	var out bytes.Buffer
+	if err := Render(&out, "svg", g, highlights); err != nil {
+		t.Fatal(err)
+	}
+
+	texts := make([]string, 0)
+	highlighted := 0
+	decoder := xml.NewDecoder(&out)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected the SVG to be valid XML, got %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rect":
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "fill" && attr.Value == hexColour(palette[0]) {
+					highlighted++
+				}
+			}
+		case "text":
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				t.Fatal(err)
+			}
+			texts = append(texts, text)
+		}
+	}
+
+	if got := strings.Join(texts, ""); got != "XMAS<&?a" {
+		t.Errorf("expected the text of every cell, got %q", got)
+	}
+	if highlighted != 4 {
+		t.Errorf("expected 4 highlighted cells, got %d", highlighted)
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	g, highlights := renderTestGrid(t)
+
+	var out bytes.Buffer
+	if err := Render(&out, "png", g, highlights); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 4*pngCellWidth || size.Y != 2*pngCellHeight {
+		t.Fatalf("expected a %dx%d image, got %dx%d", 4*pngCellWidth, 2*pngCellHeight, size.X, size.Y)
+	}
+
+	// The corner of each cell is in its margin, so it shows the cell's background
+	pixel := func(x int, y int) color.RGBA {
+		r, g, b, a := img.At(x, y).RGBA()
+		return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+	}
+	if got := pixel(pngCellWidth+1, 1); got != palette[0] {
+		t.Errorf("expected the M to be highlighted with %v, got %v", palette[0], got)
+	}
+	if got := pixel(pngCellWidth+1, pngCellHeight+1); got != background {
+		t.Errorf("expected the & not to be highlighted, got %v", got)
+	}
+
+	// The top left pixel of the ? is set in the hollow box it is drawn as
+	x, y := 2*pngCellWidth+pngMargin*pngScale, pngCellHeight+pngMargin*pngScale
+	if got := pixel(x, y); got != dimmedText {
+		t.Errorf("expected the ? to be drawn as a dimmed box, got %v", got)
+	}
+}
+
+func TestGlyphFor(t *testing.T) {
+	tests := []struct {
+		cell string
+		want [glyphHeight]string
+	}{
+		{cell: "A", want: font5x7['A']},
+		{cell: "a", want: font5x7['A']},
+		{cell: "?", want: missingGlyph},
+		{cell: "é", want: missingGlyph},
+		{cell: " ", want: [glyphHeight]string{}},
+		{cell: "", want: [glyphHeight]string{}},
+	}
+	for _, test := range tests {
+		if got := glyphFor(test.cell); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.cell, got, test.want)
+		}
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	g, highlights := renderTestGrid(t)
+	if err := Render(io.Discard, "gif", g, highlights); err == nil {
+		t.Errorf("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,365 @@
+package wordsearch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"common/grid"
+)
+
+// Semantics decides which of the matches found in a grid are counted, see Select.
+type Semantics int
+
+const (
+	// AllDirected counts every match in every direction, which is what the puzzle asks for. A word that reads the
+	// same forwards and backwards, like a palindrome, is counted once for each direction it can be read in.
+	AllDirected Semantics = iota
+	// UniqueCells counts matches covering the same cells once, so a palindrome read both ways along a line, or two
+	// words that are each other's reverse, only count once. Matches that merely share some of their cells are
+	// still counted separately.
+	UniqueCells
+	// NonOverlappingGreedy goes through the matches in the order they were found, and counts each one that doesn't
+	// share a cell with a match already counted. It is quick, but may count fewer matches than is possible.
+	NonOverlappingGreedy
+	// NonOverlappingMaximum counts the largest possible set of matches where no two of them share a cell. Finding
+	// that set is an exact search, which is done separately for each group of matches that overlap each other.
+	// It is quick for puzzle-like grids, but gives up with an error when thousands of matches overlap in a chain.
+	NonOverlappingMaximum
+)
+
+// ParseSemantics converts the name of a semantics (all, unique, greedy or maximum) to a Semantics.
+func ParseSemantics(name string) (Semantics, error) {
+	switch strings.ToLower(name) {
+	case "", "all":
+		return AllDirected, nil
+	case "unique":
+		return UniqueCells, nil
+	case "greedy":
+		return NonOverlappingGreedy, nil
+	case "maximum":
+		return NonOverlappingMaximum, nil
+	}
+	return AllDirected, fmt.Errorf("unknown match semantics %q, expected all, unique, greedy or maximum", name)
+}
+
+// Select returns the matches that are counted under the semantics, in the order they were found. Matches are
+// compared by the cells they cover in a flat or layered grid. For matches found on a torus, pass the grid's Wrap
+// as wrap, so cells that ran off the edge are brought back onto the grid before they are compared. Otherwise wrap
+// can be nil. It only fails for NonOverlappingMaximum, when the search for the largest set of matches takes too long.
+func Select(matches []Match, semantics Semantics, wrap func(grid.Point) grid.Point) ([]Match, error) {
+	cells := make([][]grid.Point3, len(matches))
+	for i, match := range matches {
+		cells[i] = match.Cells3()
+		if wrap != nil {
+			for j, cell := range cells[i] {
+				p := wrap(grid.Point{Row: cell.Row, Col: cell.Col})
+				cells[i][j] = grid.Point3{Layer: cell.Layer, Row: p.Row, Col: p.Col}
+			}
+		}
+	}
+
+	switch semantics {
+	case UniqueCells:
+		return selectUnique(matches, cells), nil
+	case NonOverlappingGreedy:
+		return selectGreedy(matches, cells), nil
+	case NonOverlappingMaximum:
+		return selectMaximum(matches, cells)
+	}
+	return matches, nil
+}
+
+// selectUnique keeps the first match covering each set of cells.
+func selectUnique(matches []Match, cells [][]grid.Point3) []Match {
+	selected := make([]Match, 0, len(matches))
+	seen := make(map[string]bool)
+	for i, match := range matches {
+		// The order the cells are read in doesn't matter, so sort them to get the same key both ways
+		sorted := append([]grid.Point3(nil), cells[i]...)
+		sort.Slice(sorted, func(a, b int) bool {
+			return lessPoint3(sorted[a], sorted[b])
+		})
+
+		key := fmt.Sprint(sorted)
+		if !seen[key] {
+			seen[key] = true
+			selected = append(selected, match)
+		}
+	}
+	return selected
+}
+
+// selectGreedy keeps each match that doesn't share a cell with a match kept before it.
+func selectGreedy(matches []Match, cells [][]grid.Point3) []Match {
+	selected := make([]Match, 0, len(matches))
+	used := make(map[grid.Point3]bool)
+	for i, match := range matches {
+		free := true
+		for _, cell := range cells[i] {
+			if used[cell] {
+				free = false
+				break
+			}
+		}
+
+		if free {
+			for _, cell := range cells[i] {
+				used[cell] = true
+			}
+			selected = append(selected, match)
+		}
+	}
+	return selected
+}
+
+// selectMaximum keeps the largest set of matches where no two share a cell. Matches overlap in small groups in
+// practice, so the matches are split into groups that only overlap among themselves, and each group is solved on
+// its own with an exact search.
+func selectMaximum(matches []Match, cells [][]grid.Point3) ([]Match, error) {
+	// Two matches conflict when they share a cell, so the matches covering each cell all conflict with each other
+	byCell := make(map[grid.Point3][]int)
+	for i := range matches {
+		for _, cell := range cells[i] {
+			byCell[cell] = append(byCell[cell], i)
+		}
+	}
+
+	s := &overlapSearch{conflicts: make([][]int, len(matches)), sharing: make([][][]int, len(matches))}
+	for i := range matches {
+		conflicts := make(map[int]bool)
+		for _, cell := range cells[i] {
+			s.sharing[i] = append(s.sharing[i], byCell[cell])
+			for _, other := range byCell[cell] {
+				if other != i {
+					conflicts[other] = true
+				}
+			}
+		}
+
+		// Keep the conflicts in order, so the search always goes the same way
+		for other := range conflicts {
+			s.conflicts[i] = append(s.conflicts[i], other)
+		}
+		sort.Ints(s.conflicts[i])
+	}
+
+	keep := make([]bool, len(matches))
+	grouped := make([]bool, len(matches))
+	for i := range matches {
+		if grouped[i] {
+			continue
+		}
+
+		// Collect every match overlapping this one, directly or through others
+		group := []int{i}
+		grouped[i] = true
+		for next := 0; next < len(group); next++ {
+			for _, other := range s.conflicts[group[next]] {
+				if !grouped[other] {
+					grouped[other] = true
+					group = append(group, other)
+				}
+			}
+		}
+
+		// Sort the group so the same set is chosen every time, when there are several of the same size
+		sort.Ints(group)
+		chosen, err := s.maximum(group)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range chosen {
+			keep[i] = true
+		}
+	}
+
+	selected := make([]Match, 0, len(matches))
+	for i, match := range matches {
+		if keep[i] {
+			selected = append(selected, match)
+		}
+	}
+	return selected, nil
+}
+
+// maxSearchSteps limits how many branches the search for the largest set of matches that don't share cells may
+// try, so that grids where huge numbers of matches overlap fail instead of running for hours.
+const maxSearchSteps = 200000
+
+// overlapSearch finds the largest set of matches where no two of them share a cell.
+type overlapSearch struct {
+	// conflicts lists the matches sharing a cell with each match, in order
+	conflicts [][]int
+	// sharing holds, for each cell of each match, every match covering that cell (including the match itself)
+	sharing [][][]int
+	best    []int
+	steps   int
+}
+
+// maximum returns the largest subset of the candidates where no two of them share a cell. It is a branch and bound
+// search, which at each step takes the candidate with the most conflicts and tries putting it in and leaving it
+// out, giving up on any branch that can't beat the best set found so far. It returns an error if the search takes
+// more than maxSearchSteps steps.
+func (s *overlapSearch) maximum(candidates []int) ([]int, error) {
+	s.best = make([]int, 0)
+	s.steps = 0
+	s.search(candidates, make([]int, 0, len(candidates)))
+
+	if s.steps > maxSearchSteps {
+		return nil, fmt.Errorf("unable to find the largest set of %d overlapping matches that don't share cells "+
+			"within %d steps, try the greedy semantics instead", len(candidates), maxSearchSteps)
+	}
+	return s.best, nil
+}
+
+func (s *overlapSearch) search(remaining []int, chosen []int) {
+	s.steps++
+	if s.steps > maxSearchSteps {
+		return
+	}
+
+	present := make(map[int]bool, len(remaining))
+	for _, candidate := range remaining {
+		present[candidate] = true
+	}
+	degree := make(map[int]int, len(remaining))
+	for _, candidate := range remaining {
+		for _, other := range s.conflicts[candidate] {
+			if present[other] {
+				degree[candidate]++
+			}
+		}
+	}
+
+	// A candidate with at most one conflict left can always be put in, since leaving it out to make room for the
+	// one it conflicts with can't lead to a bigger set. Putting it in can leave more candidates like it.
+	pendants := make([]int, 0)
+	for _, candidate := range remaining {
+		if degree[candidate] <= 1 {
+			pendants = append(pendants, candidate)
+		}
+	}
+	for len(pendants) > 0 {
+		candidate := pendants[0]
+		pendants = pendants[1:]
+		if !present[candidate] {
+			continue
+		}
+
+		chosen = append(chosen, candidate)
+		removed := []int{candidate}
+		for _, other := range s.conflicts[candidate] {
+			if present[other] {
+				removed = append(removed, other)
+			}
+		}
+		for _, gone := range removed {
+			delete(present, gone)
+		}
+		for _, gone := range removed {
+			for _, other := range s.conflicts[gone] {
+				if present[other] {
+					degree[other]--
+					if degree[other] == 1 {
+						pendants = append(pendants, other)
+					}
+				}
+			}
+		}
+	}
+
+	left := make([]int, 0, len(present))
+	for _, candidate := range remaining {
+		if present[candidate] {
+			left = append(left, candidate)
+		}
+	}
+	remaining = left
+
+	if len(remaining) == 0 {
+		if len(chosen) > len(s.best) {
+			s.best = append([]int(nil), chosen...)
+		}
+		return
+	}
+
+	if len(chosen)+s.upperBound(remaining) <= len(s.best) {
+		return
+	}
+
+	// Branch on the remaining candidate with the most conflicts, which shrinks the search the most
+	pick := 0
+	for i, candidate := range remaining {
+		if degree[candidate] > degree[remaining[pick]] {
+			pick = i
+		}
+	}
+	candidate := remaining[pick]
+
+	// Put the candidate in, which rules out everything it conflicts with
+	compatible := make([]int, 0, len(remaining))
+	delete(present, candidate)
+	for _, other := range s.conflicts[candidate] {
+		delete(present, other)
+	}
+	for _, other := range remaining {
+		if present[other] {
+			compatible = append(compatible, other)
+		}
+	}
+	s.search(compatible, append(chosen, candidate))
+
+	// Or leave it out
+	rest := make([]int, 0, len(remaining)-1)
+	rest = append(rest, remaining[:pick]...)
+	s.search(append(rest, remaining[pick+1:]...), chosen)
+}
+
+// upperBound returns a number no smaller than the most of the remaining candidates that can be kept. Only one of
+// the matches covering any one cell can be kept, so the candidates are split into groups sharing a cell, and the
+// number of groups is the bound. Each group is made as big as possible, to keep the bound tight.
+func (s *overlapSearch) upperBound(remaining []int) int {
+	present := make(map[int]bool, len(remaining))
+	for _, candidate := range remaining {
+		present[candidate] = true
+	}
+
+	groups := 0
+	for _, candidate := range remaining {
+		if !present[candidate] {
+			continue
+		}
+
+		// Pick the cell of the candidate shared with the most candidates that aren't in a group yet
+		var biggest []int
+		biggestSize := -1
+		for _, sharing := range s.sharing[candidate] {
+			size := 0
+			for _, other := range sharing {
+				if present[other] {
+					size++
+				}
+			}
+			if size > biggestSize {
+				biggest, biggestSize = sharing, size
+			}
+		}
+
+		for _, other := range biggest {
+			delete(present, other)
+		}
+		groups++
+	}
+	return groups
+}
+
+func lessPoint3(a grid.Point3, b grid.Point3) bool {
+	if a.Layer != b.Layer {
+		return a.Layer < b.Layer
+	}
+	if a.Row != b.Row {
+		return a.Row < b.Row
+	}
+	return a.Col < b.Col
+}
@@ -0,0 +1,204 @@
+package wordsearch
+
+import (
+	"math/rand"
+	"testing"
+
+	"common/grid"
+)
+
+// findIn searches a small grid written as one string per row for the words.
+func findIn(t *testing.T, rows []string, words ...string) ([]Match, *grid.Grid[string]) {
+	t.Helper()
+
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = grid.Characters(row)
+	}
+	g, err := grid.FromRows(cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewTextMatcher(words, TextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matcher.FindAll(g), g
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  []string
+		words []string
+		// want holds the number of matches counted under each semantics
+		want map[Semantics]int
+	}{
+		{
+			// A palindrome is read once in each direction along its line
+			name:  "palindrome",
+			rows:  []string{"ABA"},
+			words: []string{"ABA"},
+			want:  map[Semantics]int{AllDirected: 2, UniqueCells: 1, NonOverlappingGreedy: 1, NonOverlappingMaximum: 1},
+		},
+		{
+			// Two different words that are each other's reverse cover the same cells, each read once in its own direction
+			name:  "reversed words",
+			rows:  []string{"XMAS"},
+			words: []string{"XMAS", "SAMX"},
+			want:  map[Semantics]int{AllDirected: 2, UniqueCells: 1, NonOverlappingGreedy: 1, NonOverlappingMaximum: 1},
+		},
+		{
+			// Two words crossing on the A share a cell, but not all of their cells
+			name: "crossing",
+			rows: []string{
+				"X...X",
+				".M.M.",
+				"..A..",
+				".S.S.",
+			},
+			words: []string{"XMAS"},
+			want:  map[Semantics]int{AllDirected: 2, UniqueCells: 2, NonOverlappingGreedy: 1, NonOverlappingMaximum: 1},
+		},
+		{
+			// Up is the first direction searched, so greedy takes the BQB reading up the second column first,
+			// which blocks both of the ABs. The maximum keeps the two ABs instead.
+			name: "greedy misses the maximum",
+			rows: []string{
+				"AB",
+				"QQ",
+				"AB",
+			},
+			words: []string{"AB", "BQB"},
+			want:  map[Semantics]int{AllDirected: 4, UniqueCells: 3, NonOverlappingGreedy: 1, NonOverlappingMaximum: 2},
+		},
+		{
+			name:  "no matches",
+			rows:  []string{"QQQ", "QQQ"},
+			words: []string{"XMAS"},
+			want:  map[Semantics]int{AllDirected: 0, UniqueCells: 0, NonOverlappingGreedy: 0, NonOverlappingMaximum: 0},
+		},
+	}
+
+	for _, test := range tests {
+		matches, _ := findIn(t, test.rows, test.words...)
+		for semantics, want := range test.want {
+			selected, err := Select(matches, semantics, nil)
+			if err != nil {
+				t.Fatalf("%s: semantics %d failed: %v", test.name, semantics, err)
+			}
+			if len(selected) != want {
+				t.Errorf("%s: semantics %d selected %d matches, want %d", test.name, semantics, len(selected), want)
+			}
+		}
+	}
+}
+
+func TestSelectMaximumMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	letters := []string{"A", "B", "C"}
+	checked := 0
+
+	for trial := 0; trial < 200; trial++ {
+		rows := make([]string, 3+rng.Intn(2))
+		for i := range rows {
+			row := ""
+			for j := 0; j < 4; j++ {
+				row += letters[rng.Intn(len(letters))]
+			}
+			rows[i] = row
+		}
+
+		matches, _ := findIn(t, rows, "AB", "ABC", "CAC")
+		if len(matches) > 12 {
+			continue
+		}
+
+		checked++
+		want := bruteForceMaximum(matches)
+		selected, err := Select(matches, NonOverlappingMaximum, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(selected) != want {
+			t.Errorf("%v: maximum selected %d matches, brute force found %d", rows, len(selected), want)
+		}
+		if overlapping(selected) {
+			t.Errorf("%v: maximum selected matches that share cells", rows)
+		}
+
+		greedy, _ := Select(matches, NonOverlappingGreedy, nil)
+		if len(greedy) > want || overlapping(greedy) {
+			t.Errorf("%v: greedy selected %d matches, at most %d that don't share cells were expected",
+				rows, len(greedy), want)
+		}
+	}
+
+	if checked < 100 {
+		t.Errorf("only %d of the random grids were small enough to check", checked)
+	}
+}
+
+func TestSelectTorus(t *testing.T) {
+	cells := [][]string{grid.Characters("BAA")}
+	g, err := grid.FromRows(cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewTextMatcher([]string{"AB"}, TextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// AB reads from the last cell around to the first, and back from the middle cell to the first. With only one
+	// row, the diagonals wrap around onto the same row too. Every match shares the B, but only once the cells that
+	// ran off the edge are wrapped.
+	matches := matcher.FindAllTorus(g)
+
+	unwrapped, err := Select(matches, NonOverlappingGreedy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := Select(matches, NonOverlappingGreedy, g.Wrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unwrapped) != 2 || len(wrapped) != 1 {
+		t.Errorf("expected 2 matches that don't share cells without wrapping and 1 with, got %d and %d",
+			len(unwrapped), len(wrapped))
+	}
+}
+
+// bruteForceMaximum tries every subset of the matches to find the most that don't share cells.
+func bruteForceMaximum(matches []Match) int {
+	best := 0
+	for subset := 0; subset < 1<<len(matches); subset++ {
+		chosen := make([]Match, 0)
+		for i, match := range matches {
+			if subset&(1<<i) != 0 {
+				chosen = append(chosen, match)
+			}
+		}
+		if len(chosen) > best && !overlapping(chosen) {
+			best = len(chosen)
+		}
+	}
+	return best
+}
+
+// overlapping reports whether any two of the matches share a cell.
+func overlapping(matches []Match) bool {
+	used := make(map[grid.Point]bool)
+	for _, match := range matches {
+		for _, cell := range match.Cells() {
+			if used[cell] {
+				return true
+			}
+			used[cell] = true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package wordsearch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"common/grid"
+)
+
+// Segmentation decides what makes up a single cell when splitting text.
+type Segmentation int
+
+const (
+	// SplitCharacters makes every Unicode code point its own cell, like strings.Split(line, "").
+	// Combining characters end up in a cell of their own, separate from the character they modify.
+	SplitCharacters Segmentation = iota
+	// SplitGraphemes makes every grapheme cluster (what a reader sees as a single character) its own cell,
+	// so combining characters stay with the character they modify.
+	SplitGraphemes
+)
+
+// Normalization selects the Unicode normalization form applied to text before it is split into cells.
+type Normalization int
+
+const (
+	// NoNormalization leaves text as it is, so precomposed and decomposed characters don't match each other.
+	NoNormalization Normalization = iota
+	// NFC composes characters where possible, so "e" followed by a combining acute accent becomes "é".
+	NFC
+	// NFD decomposes characters, so "é" becomes "e" followed by a combining acute accent.
+	NFD
+)
+
+// TextOptions controls how text is turned into cells and how cells are compared. The same options must be used
+// for the grid and the words, which NewTextMatcher and Split take care of.
+type TextOptions struct {
+	Segmentation  Segmentation
+	Normalization Normalization
+	// FoldCase compares cells without regard to case, using full Unicode case folding. Each cell is folded on its
+	// own, so a cell can fold to more than one letter: ß folds to "ss" and matches ẞ, which does too, but never the
+	// two cells of SS.
+	FoldCase bool
+}
+
+// ParseNormalization converts the name of a normalization form (nfc, nfd, or none) to a Normalization.
+func ParseNormalization(name string) (Normalization, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return NoNormalization, nil
+	case "nfc":
+		return NFC, nil
+	case "nfd":
+		return NFD, nil
+	}
+	return NoNormalization, fmt.Errorf("unknown normalization form %q, expected nfc, nfd or none", name)
+}
+
+// Split normalizes the text and splits it into cells. It can be passed to grid.Load to load a grid of text.
+func (o TextOptions) Split(text string) []string {
+	text = o.normalize(text)
+
+	if o.Segmentation == SplitCharacters {
+		return strings.Split(text, "")
+	}
+
+	cells := make([]string, 0, len(text))
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		cells = append(cells, graphemes.Str())
+	}
+	return cells
+}
+
+// Key returns the form of a cell used to compare it with other cells.
+func (o TextOptions) Key(cell string) string {
+	if o.FoldCase {
+		// Folding can undo normalization (and normalizing can undo folding), so fold first and normalize after
+		cell = cases.Fold().String(cell)
+	}
+	return o.normalize(cell)
+}
+
+func (o TextOptions) normalize(text string) string {
+	switch o.Normalization {
+	case NFC:
+		return norm.NFC.String(text)
+	case NFD:
+		return norm.NFD.String(text)
+	}
+	return text
+}
+
+// TextMatcher finds words in a grid of text cells, splitting and comparing them according to its TextOptions.
+type TextMatcher struct {
+	opts    TextOptions
+	matcher *Matcher[string]
+}
+
+// NewTextMatcher builds a matcher for the words, split into cells with the options.
+func NewTextMatcher(words []string, opts TextOptions) (*TextMatcher, error) {
+	keyedWords := make([][]string, len(words))
+	for i, word := range words {
+		cells := opts.Split(word)
+		for j, cell := range cells {
+			cells[j] = opts.Key(cell)
+		}
+		keyedWords[i] = cells
+	}
+
+	matcher, err := NewMatcher(keyedWords)
+	if err != nil {
+		return nil, err
+	}
+	return &TextMatcher{opts: opts, matcher: matcher}, nil
+}
+
+// FindAll returns every occurrence of every word in the grid, comparing the cells by their keys.
+// The grid should have been loaded by passing the same options' Split to grid.Load.
+func (m *TextMatcher) FindAll(g *grid.Grid[string]) []Match {
+	return m.matcher.FindAll(m.keys(g))
+}
+
+// FindAllTorus is FindAll on a grid that wraps around at the edges, see Matcher.FindAllTorus.
+func (m *TextMatcher) FindAllTorus(g *grid.Grid[string]) []Match {
+	return m.matcher.FindAllTorus(m.keys(g))
+}
+
+// FindAll3D is FindAll on a layered grid, see Matcher.FindAll3D.
+func (m *TextMatcher) FindAll3D(g *grid.Grid3[string]) []Match {
+	keys := grid.New3[string](g.Layers(), g.Rows(), g.Cols())
+	for layer := 0; layer < g.Layers(); layer++ {
+		for row := 0; row < g.Rows(); row++ {
+			for col := 0; col < g.Cols(); col++ {
+				p := grid.Point3{Layer: layer, Row: row, Col: col}
+				keys.Set(p, m.opts.Key(g.At(p)))
+			}
+		}
+	}
+	return m.matcher.FindAll3D(keys)
+}
+
+// keys returns a copy of the grid with every cell replaced by its key.
+func (m *TextMatcher) keys(g *grid.Grid[string]) *grid.Grid[string] {
+	keys := grid.New[string](g.Rows(), g.Cols())
+	g.ForEach(func(p grid.Point, cell string) {
+		keys.Set(p, m.opts.Key(cell))
+	})
+	return keys
+}
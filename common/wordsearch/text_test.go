@@ -0,0 +1,200 @@
+package wordsearch
+
+import (
+	"reflect"
+	"testing"
+
+	"common/grid"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts TextOptions
+		want []string
+	}{
+		{
+			name: "characters",
+			text: "XMAS",
+			want: []string{"X", "M", "A", "S"},
+		},
+		{
+			// An e followed by a combining acute accent is two code points but a single grapheme
+			name: "combining accent as characters",
+			text: "cafe\u0301",
+			want: []string{"c", "a", "f", "e", "\u0301"},
+		},
+		{
+			name: "combining accent as graphemes",
+			text: "cafe\u0301",
+			opts: TextOptions{Segmentation: SplitGraphemes},
+			want: []string{"c", "a", "f", "e\u0301"},
+		},
+		{
+			// Composing first turns the accented e into a single code point
+			name: "combining accent composed",
+			text: "cafe\u0301",
+			opts: TextOptions{Normalization: NFC},
+			want: []string{"c", "a", "f", "\u00e9"},
+		},
+		{
+			name: "flag emoji as graphemes",
+			text: "a\U0001F1EC\U0001F1E7b",
+			opts: TextOptions{Segmentation: SplitGraphemes},
+			want: []string{"a", "\U0001F1EC\U0001F1E7", "b"},
+		},
+	}
+	for _, test := range tests {
+		if got := test.opts.Split(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTextMatcher(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  []string
+		words []string
+		opts  TextOptions
+		// want is the number of matches
+		want int
+	}{
+		{
+			name:  "grapheme in a cell",
+			rows:  []string{"cafe\u0301"},
+			words: []string{"fe\u0301"},
+			opts:  TextOptions{Segmentation: SplitGraphemes},
+			want:  1,
+		},
+		{
+			// The accented e is a single cell, so the unaccented word doesn't match part of it
+			name:  "grapheme kept whole",
+			rows:  []string{"cafe\u0301"},
+			words: []string{"caf", "fe"},
+			opts:  TextOptions{Segmentation: SplitGraphemes},
+			want:  1,
+		},
+		{
+			// Without graphemes the accent is a cell of its own, after a plain e
+			name:  "grapheme split into characters",
+			rows:  []string{"cafe\u0301"},
+			words: []string{"caf", "fe"},
+			want:  2,
+		},
+		{
+			name:  "case matters by default",
+			rows:  []string{"xmas"},
+			words: []string{"XMAS"},
+			want:  0,
+		},
+		{
+			name:  "fold case",
+			rows:  []string{"xMaS"},
+			words: []string{"XMAS"},
+			opts:  TextOptions{FoldCase: true},
+			want:  1,
+		},
+		{
+			name:  "fold case beyond ASCII",
+			rows:  []string{"ΣΟΦΙΑ"},
+			words: []string{"σοφια"},
+			opts:  TextOptions{FoldCase: true},
+			want:  1,
+		},
+		{
+			// Folding works a cell at a time, so ß in a single cell folds to "ss" and can only match a cell that
+			// also folds to "ss", never the two cells of a word spelled with SS
+			name:  "sharp s folds within its cell",
+			rows:  []string{"STRAßE"},
+			words: []string{"STRASSE"},
+			opts:  TextOptions{FoldCase: true},
+			want:  0,
+		},
+		{
+			name:  "sharp s matches itself folded",
+			rows:  []string{"STRAßE"},
+			words: []string{"strasse", "straße"},
+			opts:  TextOptions{FoldCase: true},
+			want:  1,
+		},
+		{
+			name:  "capital sharp s",
+			rows:  []string{"STRAẞE"},
+			words: []string{"straße"},
+			opts:  TextOptions{FoldCase: true},
+			want:  1,
+		},
+		{
+			name:  "NFD grid with NFC word unnormalized",
+			rows:  []string{"cafe\u0301"},
+			words: []string{"caf\u00e9"},
+			opts:  TextOptions{Segmentation: SplitGraphemes},
+			want:  0,
+		},
+		{
+			name:  "NFD grid with NFC word under NFC",
+			rows:  []string{"cafe\u0301"},
+			words: []string{"caf\u00e9"},
+			opts:  TextOptions{Normalization: NFC},
+			want:  1,
+		},
+		{
+			name:  "NFC grid with NFD word under NFD",
+			rows:  []string{"caf\u00e9"},
+			words: []string{"cafe\u0301"},
+			opts:  TextOptions{Segmentation: SplitGraphemes, Normalization: NFD},
+			want:  1,
+		},
+		{
+			name:  "fold case and normalize",
+			rows:  []string{"CAFE\u0301"},
+			words: []string{"caf\u00e9"},
+			opts:  TextOptions{Normalization: NFC, FoldCase: true},
+			want:  1,
+		},
+	}
+	for _, test := range tests {
+		cells := make([][]string, len(test.rows))
+		for i, row := range test.rows {
+			cells[i] = test.opts.Split(row)
+		}
+		g, err := grid.FromRows(cells)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		matcher, err := NewTextMatcher(test.words, test.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := len(matcher.FindAll(g)); got != test.want {
+			t.Errorf("%s: got %d matches, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseNormalization(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Normalization
+		wantErr bool
+	}{
+		{name: "", want: NoNormalization},
+		{name: "none", want: NoNormalization},
+		{name: "nfc", want: NFC},
+		{name: "NFD", want: NFD},
+		{name: "nfkc", wantErr: true},
+		{name: "bogus", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseNormalization(test.name)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want an error: %t", test.name, err, test.wantErr)
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%q: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
+
+	"common/bigsum"
 )
 
 // Result should be 161 - 2*4 + 5*5 + 11*8 + 8*5
@@ -62,25 +64,19 @@ func parseInputFile(filename string) (string, error) {
 
 // multiplyAndAdd takes a list of strings, where each string is in the format specified above
 // and parses them, performing multiplication actions and returns the sum of the multiplications.
-func multiplyAndAdd(matches []string) (int, error) {
-	var sum int
+// The sum is returned as a big.Int, since nothing guarantees that it fits in an int.
+func multiplyAndAdd(matches []string) (*big.Int, error) {
+	var sum bigsum.Sum
 	for _, match := range matches {
 		indexOpenParen := strings.Index(match, "(")
 		indexCloseParen := strings.Index(match, ")")
 		indexComma := strings.Index(match, ",")
 
-		firstTerm, err := strconv.Atoi(match[indexOpenParen+1 : indexComma])
+		err := sum.AddProduct(match[indexOpenParen+1:indexComma], match[indexComma+1:indexCloseParen])
 		if err != nil {
-			return -1, fmt.Errorf("unable to convert first term of %s to integer due to: %w", match, err)
+			return nil, fmt.Errorf("unable to multiply the terms of %s due to: %w", match, err)
 		}
-
-		secondTerm, err := strconv.Atoi(match[indexComma+1 : indexCloseParen])
-		if err != nil {
-			return -1, fmt.Errorf("unable to convert second term of %s to integer due to: %w", match, err)
-		}
-
-		sum += firstTerm * secondTerm
 	}
 
-	return sum, nil
+	return sum.Value(), nil
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// mulRegex is the same expression main compiles to find the valid commands
+var mulRegex = regexp.MustCompile(`mul\(\d{1,3},\d{1,3}\)`)
+
+func TestMultiplyAndAdd(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "example", input: testInput, want: "161"},
+		{name: "no commands", input: "mul(1,2]mul(1234,5)", want: "0"},
+		{name: "largest terms", input: "mul(999,999)mul(999,999)", want: "1996002"},
+	}
+	for _, test := range tests {
+		got, err := multiplyAndAdd(mulRegex.FindAllString(test.input, -1))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMultiplyAndAddTooManyDigits(t *testing.T) {
+	// The regex never lets through more than 3 digits, but multiplyAndAdd itself doesn't rely on that
+	got, err := multiplyAndAdd([]string{"mul(9223372036854775807,2)", "mul(1,2)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "18446744073709551616"; got.String() != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
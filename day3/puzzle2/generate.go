@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+
+	"common/bigsum"
+)
+
+// fillerCharacters are used to pad out the noise between instructions. None of them are an "m" or a "d",
+// so a run of filler can never start an instruction of its own.
+const fillerCharacters = "!@#$%^&*()[]{}<>?/|;:'\",.+-_~ 0123456789abcefghijklnopqrstuvwxyz"
+
+// nearMisses are fragments that look like instructions but aren't, taken from the kinds of corruption found in the
+// puzzle input. None of them contain a valid instruction, and none of them end with the start of one, so they
+// can't combine with whatever comes after them into an instruction either.
+var nearMisses = []string{
+	"mul[3,7]", "mul(32,64]", "mul(11,8]", "mul(4*", "mul(6,9!", "mul ( 2 , 4 )", "mul(1234,5)", "mul(12,3456)",
+	"mul(,1)", "mul(-1,2)", "don't[]", "do_not_", "don'x", "do(]", "why()", "select()", "where()", "from()",
+	"what()", "who()", "when()", "how()", "how(445,327)",
+}
+
+// generatorOptions controls the shape of the corrupted memory built by generateMemory.
+type generatorOptions struct {
+	// seed makes the output reproducible, the same options always produce the same memory
+	seed int64
+	// size is the minimum length of the memory in bytes
+	size int
+	// noiseRatio is the fraction of the memory, in bytes, that is made up of noise instead of instructions
+	noiseRatio float64
+	// toggleDensity is the fraction of instructions that are do() or don't() instead of mul(X,Y)
+	toggleDensity float64
+}
+
+// generatedMemory is a corrupted memory string along with the answers to both parts of the puzzle for it.
+type generatedMemory struct {
+	memory string
+	part1  *big.Int
+	part2  *big.Int
+}
+
+// generateMemory builds corrupted memory in the style of the puzzle input. Since every instruction is planted
+// on purpose, and the noise around them can never form an instruction by accident, the answers are known exactly
+// without having to parse the result.
+func generateMemory(opts generatorOptions) (generatedMemory, error) {
+	if opts.size < 1 {
+		return generatedMemory{}, fmt.Errorf("size must be positive, got %d", opts.size)
+	}
+	if opts.noiseRatio < 0 || opts.noiseRatio >= 1 {
+		return generatedMemory{}, fmt.Errorf("noise ratio must be at least 0 and below 1, got %g", opts.noiseRatio)
+	}
+	if opts.toggleDensity < 0 || opts.toggleDensity > 1 {
+		return generatedMemory{}, fmt.Errorf("do/don't density must be between 0 and 1, got %g", opts.toggleDensity)
+	}
+
+	rng := rand.New(rand.NewSource(opts.seed))
+
+	var builder strings.Builder
+	var part1, part2 bigsum.Sum
+	include := true
+	noiseBytes := 0
+
+	for builder.Len() < opts.size {
+		// Add noise whenever the memory has less of it than requested, which keeps the ratio close to the target
+		// while the random length of each piece stops the layout from becoming regular.
+		if float64(noiseBytes) < opts.noiseRatio*float64(builder.Len()) {
+			noise := generateNoise(rng)
+			noiseBytes += len(noise)
+			builder.WriteString(noise)
+			continue
+		}
+
+		if rng.Float64() < opts.toggleDensity {
+			include = rng.Intn(2) == 0
+			if include {
+				builder.WriteString(doCommand)
+			} else {
+				builder.WriteString(dontCommand)
+			}
+			continue
+		}
+
+		firstTerm := generateTerm(rng)
+		secondTerm := generateTerm(rng)
+		fmt.Fprintf(&builder, "mul(%s,%s)", firstTerm, secondTerm)
+
+		if err := part1.AddProduct(firstTerm, secondTerm); err != nil {
+			return generatedMemory{}, err
+		}
+		if include {
+			if err := part2.AddProduct(firstTerm, secondTerm); err != nil {
+				return generatedMemory{}, err
+			}
+		}
+	}
+
+	return generatedMemory{memory: builder.String(), part1: part1.Value(), part2: part2.Value()}, nil
+}
+
+// generateTerm returns a random 1-3 digit term for a mul(X,Y) instruction.
+func generateTerm(rng *rand.Rand) string {
+	switch rng.Intn(3) {
+	case 0:
+		return fmt.Sprint(rng.Intn(10))
+	case 1:
+		return fmt.Sprint(rng.Intn(100))
+	default:
+		return fmt.Sprint(rng.Intn(1000))
+	}
+}
+
+// generateNoise returns a random near miss or a short run of filler characters.
+func generateNoise(rng *rand.Rand) string {
+	if rng.Intn(3) == 0 {
+		return nearMisses[rng.Intn(len(nearMisses))]
+	}
+
+	filler := make([]byte, 1+rng.Intn(6))
+	for i := range filler {
+		filler[i] = fillerCharacters[rng.Intn(len(fillerCharacters))]
+	}
+	return string(filler)
+}
+
+// runGenerate implements the generate subcommand, which writes generated memory to a file and prints the answers.
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "seed for the random number generator")
+	size := flags.Int("size", 20000, "minimum size of the generated memory in bytes")
+	noise := flags.Float64("noise", 0.6, "fraction of the memory made up of noise")
+	toggles := flags.Float64("toggles", 0.05, "fraction of instructions that are do() or don't()")
+	out := flags.String("out", "generated.txt", "file to write the generated memory to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	generated, err := generateMemory(generatorOptions{
+		seed:          *seed,
+		size:          *size,
+		noiseRatio:    *noise,
+		toggleDensity: *toggles,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, []byte(generated.memory), 0644); err != nil {
+		return fmt.Errorf("unable to write generated memory due to: %w", err)
+	}
+
+	fmt.Printf("Wrote %d bytes of corrupted memory to %s\n", len(generated.memory), *out)
+	fmt.Printf("Part 1: %d\n", generated.part1)
+	fmt.Printf("Part 2: %d\n", generated.part2)
+	return nil
+}
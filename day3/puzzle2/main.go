@@ -1,12 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
+
+	"common/bigsum"
 )
 
 // Result should be 161 - 2*4 + 5*5 + 11*8 + 8*5
@@ -18,6 +21,21 @@ func main() {
 	// Invalid characters should be ignored.
 	// Additionally, do() and don't() commands should be parsed and handled. A don't() command should cause
 	// all mul() functions after it to be ignored from the total sum until a do() command is encountered.
+	workers := flag.Int("workers", 1, "number of goroutines used to evaluate the input, values above 1 use the parallel evaluator")
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "repl":
+		if err := runREPL(os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	case "generate":
+		if err := runGenerate(flag.Args()[1:]); err != nil {
+			panic(err)
+		}
+		return
+	}
 
 	// Build the regex to parse valid commands
 	rx, err := regexp.Compile(`(mul\(\d{1,3},\d{1,3}\))|(do\(\))|(don't\(\))`)
@@ -32,11 +50,17 @@ func main() {
 		panic(err)
 	}
 
-	matches := rx.FindAllString(input, -1)
+	var sum *big.Int
+	if *workers > 1 {
+		// Each chunk of the input is evaluated on its own goroutine, see multiplyAndAddParallel
+		sum, err = multiplyAndAddParallel(rx, input, *workers)
+	} else {
+		matches := rx.FindAllString(input, -1)
 
-	fmt.Printf("All matches to the regex are: %v\n", matches)
+		fmt.Printf("All matches to the regex are: %v\n", matches)
 
-	sum, err := multiplyAndAdd(matches)
+		sum, err = multiplyAndAdd(matches)
+	}
 
 	if err != nil {
 		panic(err)
@@ -68,8 +92,9 @@ const dontCommand = "don't()"
 // multiplyAndAdd takes a list of strings, where each string is in the format specified above
 // and parses them, performing multiplication actions and returns the sum of the multiplications.
 // Also has special handling for do() and don't() commands, which control enabling and disabling of multiplication operations.
-func multiplyAndAdd(matches []string) (int, error) {
-	var sum int
+// The sum is returned as a big.Int, since nothing guarantees that it fits in an int.
+func multiplyAndAdd(matches []string) (*big.Int, error) {
+	var sum bigsum.Sum
 	include := true
 	for _, match := range matches {
 		if match == doCommand {
@@ -85,22 +110,24 @@ func multiplyAndAdd(matches []string) (int, error) {
 		}
 		// After this point, we know we have a mul() command and can safely parse it.
 
-		indexOpenParen := strings.Index(match, "(")
-		indexCloseParen := strings.Index(match, ")")
-		indexComma := strings.Index(match, ",")
-
-		firstTerm, err := strconv.Atoi(match[indexOpenParen+1 : indexComma])
-		if err != nil {
-			return -1, fmt.Errorf("unable to convert first term of %s to integer due to: %w", match, err)
+		if err := addMultiplication(&sum, match); err != nil {
+			return nil, err
 		}
+	}
 
-		secondTerm, err := strconv.Atoi(match[indexComma+1 : indexCloseParen])
-		if err != nil {
-			return -1, fmt.Errorf("unable to convert second term of %s to integer due to: %w", match, err)
-		}
+	return sum.Value(), nil
+}
+
+// addMultiplication parses a single mul(X,Y) command and adds the product of its two terms to the sum.
+func addMultiplication(sum *bigsum.Sum, match string) error {
+	indexOpenParen := strings.Index(match, "(")
+	indexCloseParen := strings.Index(match, ")")
+	indexComma := strings.Index(match, ",")
 
-		sum += firstTerm * secondTerm
+	err := sum.AddProduct(match[indexOpenParen+1:indexComma], match[indexComma+1:indexCloseParen])
+	if err != nil {
+		return fmt.Errorf("unable to multiply the terms of %s due to: %w", match, err)
 	}
 
-	return sum, nil
+	return nil
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// part1Regex and part2Regex are the same expressions main compiles for each part of the puzzle
+var (
+	part1Regex = regexp.MustCompile(`mul\(\d{1,3},\d{1,3}\)`)
+	part2Regex = regexp.MustCompile(`(mul\(\d{1,3},\d{1,3}\))|(do\(\))|(don't\(\))`)
+)
+
+// mustGenerate wraps generateMemory for tests, failing the test if the options are rejected.
+func mustGenerate(tb testing.TB, opts generatorOptions) generatedMemory {
+	tb.Helper()
+	generated, err := generateMemory(opts)
+	if err != nil {
+		tb.Fatalf("unable to generate memory for %+v: %v", opts, err)
+	}
+	return generated
+}
+
+// checkGeneratedAnswers fails the test if the regex path disagrees with the answers the generator planted.
+func checkGeneratedAnswers(t *testing.T, generated generatedMemory) {
+	t.Helper()
+	part1, err := multiplyAndAdd(part1Regex.FindAllString(generated.memory, -1))
+	if err != nil {
+		t.Fatalf("regex evaluation of part 1 failed: %v", err)
+	}
+	if part1.Cmp(generated.part1) != 0 {
+		t.Errorf("part 1: regex got %d, generator expected %d", part1, generated.part1)
+	}
+
+	part2, err := multiplyAndAdd(part2Regex.FindAllString(generated.memory, -1))
+	if err != nil {
+		t.Fatalf("regex evaluation of part 2 failed: %v", err)
+	}
+	if part2.Cmp(generated.part2) != 0 {
+		t.Errorf("part 2: regex got %d, generator expected %d", part2, generated.part2)
+	}
+}
+
+func TestGeneratedMemory(t *testing.T) {
+	fixtures := []generatorOptions{
+		{seed: 1, size: 20000, noiseRatio: 0.6, toggleDensity: 0.05},
+		{seed: 2, size: 5000, noiseRatio: 0, toggleDensity: 0.5},
+		{seed: 3, size: 5000, noiseRatio: 0.95, toggleDensity: 0},
+		{seed: 4, size: 100_000, noiseRatio: 0.3, toggleDensity: 1},
+	}
+
+	for _, opts := range fixtures {
+		generated := mustGenerate(t, opts)
+		checkGeneratedAnswers(t, generated)
+		compareEvaluators(t, generated.memory)
+
+		again := mustGenerate(t, opts)
+		if again.memory != generated.memory {
+			t.Errorf("generating %+v twice produced different memory", opts)
+		}
+	}
+}
+
+func TestGenerateMemoryRejectsBadOptions(t *testing.T) {
+	for _, opts := range []generatorOptions{
+		{size: 0},
+		{size: 10, noiseRatio: -0.1},
+		{size: 10, noiseRatio: 1},
+		{size: 10, toggleDensity: 1.5},
+	} {
+		if _, err := generateMemory(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestMultiplyAndAddParallelMatchesSequential(t *testing.T) {
+	inputs := map[string]string{
+		"testInput": testInput,
+		"generated": mustGenerate(t, generatorOptions{seed: 1, size: 100_000, noiseRatio: 0.5, toggleDensity: 0.1}).memory,
+	}
+	if fileInput, err := parseInputFile("input.txt"); err == nil {
+		inputs["input.txt"] = fileInput
+	}
+
+	for name, input := range inputs {
+		want, err := multiplyAndAdd(part2Regex.FindAllString(input, -1))
+		if err != nil {
+			t.Fatalf("%s: sequential evaluation failed: %v", name, err)
+		}
+
+		for _, workers := range []int{1, 2, 3, 7, 64} {
+			got, err := multiplyAndAddParallel(part2Regex, input, workers)
+			if err != nil {
+				t.Fatalf("%s with %d workers: parallel evaluation failed: %v", name, workers, err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("%s with %d workers: got %d, want %d", name, workers, got, want)
+			}
+		}
+	}
+}
+
+// compareEvaluators evaluates the input with the regex path, the streaming parser and the parallel evaluator,
+// and fails the test if any of them disagree.
+func compareEvaluators(t *testing.T, input string) {
+	part1, err := multiplyAndAdd(part1Regex.FindAllString(input, -1))
+	if err != nil {
+		t.Fatalf("regex evaluation of part 1 failed: %v", err)
+	}
+
+	streamed, err := evaluateStream(strings.NewReader(input), false)
+	if err != nil {
+		t.Fatalf("streaming evaluation of part 1 failed: %v", err)
+	}
+	if streamed.Cmp(part1) != 0 {
+		t.Errorf("part 1 of %q: streaming parser got %d, regex got %d", input, streamed, part1)
+	}
+
+	part2, err := multiplyAndAdd(part2Regex.FindAllString(input, -1))
+	if err != nil {
+		t.Fatalf("regex evaluation of part 2 failed: %v", err)
+	}
+
+	streamed, err = evaluateStream(strings.NewReader(input), true)
+	if err != nil {
+		t.Fatalf("streaming evaluation of part 2 failed: %v", err)
+	}
+	if streamed.Cmp(part2) != 0 {
+		t.Errorf("part 2 of %q: streaming parser got %d, regex got %d", input, streamed, part2)
+	}
+
+	for _, workers := range []int{2, 5} {
+		parallel, err := multiplyAndAddParallel(part2Regex, input, workers)
+		if err != nil {
+			t.Fatalf("parallel evaluation with %d workers failed: %v", workers, err)
+		}
+		if parallel.Cmp(part2) != 0 {
+			t.Errorf("part 2 of %q: parallel evaluator with %d workers got %d, regex got %d", input, workers, parallel, part2)
+		}
+	}
+}
+
+// FuzzEvaluators feeds arbitrary strings through every evaluator. The seeds below, plus the saved corpus in
+// testdata/fuzz/FuzzEvaluators, cover the near misses found in the puzzle input.
+func FuzzEvaluators(f *testing.F) {
+	f.Add(testInput)
+	f.Add("xmul(2,4)%&mul[3,7]!@^do_not_mul(5,5)+mul(32,64]then(mul(11,8)mul(8,5))")
+	f.Add("mul(1234,5)mul(12,3456)mul(,1)mul(1,)mul( 1,2)mul(1,2 )")
+	f.Add("don't()mul(1,1)undo()mul(2,2)don't(mul(3,3)do(mul(4,4)")
+	f.Add("mumul(1,1)mmul(2,2)domul(3,3)dodon't()mul(4,4)")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		compareEvaluators(t, input)
+	})
+}
+
+// FuzzSyntheticMemory runs the generator with random options, which produces instructions and near misses in far
+// larger numbers than random mutation of raw strings manages. Every evaluator must agree with the planted answers.
+func FuzzSyntheticMemory(f *testing.F) {
+	f.Add(int64(1), uint16(100), uint8(60), uint8(5))
+	f.Add(int64(2024), uint16(4096), uint8(20), uint8(50))
+
+	f.Fuzz(func(t *testing.T, seed int64, size uint16, noisePercent uint8, togglePercent uint8) {
+		generated, err := generateMemory(generatorOptions{
+			seed:          seed,
+			size:          int(size) + 1,
+			noiseRatio:    float64(noisePercent%100) / 100,
+			toggleDensity: float64(togglePercent%101) / 100,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkGeneratedAnswers(t, generated)
+		compareEvaluators(t, generated.memory)
+	})
+}
+
+func BenchmarkMultiplyAndAdd(b *testing.B) {
+	input := mustGenerate(b, generatorOptions{seed: 1, size: 2_000_000, noiseRatio: 0.5, toggleDensity: 0.05}).memory
+
+	b.Run("sequential", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			if _, err := multiplyAndAdd(part2Regex.FindAllString(input, -1)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel-%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				if _, err := multiplyAndAddParallel(part2Regex, input, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestREPL(t *testing.T) {
+	snippet := filepath.Join(t.TempDir(), "snippet.txt")
+	if err := os.WriteFile(snippet, []byte("mul(3,3)xxdon't()mul(2,2)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"mul(2,3)",
+		"don't()mul(4,5)",
+		"do()mul(1,1)",
+		":part 1",
+		"don't()mul(2,2)",
+		":reset",
+		":load " + snippet,
+		":bogus",
+		":quit",
+		"mul(9,9)",
+	}, "\n")
+
+	var out strings.Builder
+	if err := runREPL(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// The state is shown after every snippet and every command that changes it
+	states := regexp.MustCompile(`part \d \| enabled: \w+ \| sum: \d+`).FindAllString(out.String(), -1)
+	want := []string{
+		"part 2 | enabled: true | sum: 6",
+		// The sum carries over, and don't() stays in effect until the next do()
+		"part 2 | enabled: false | sum: 6",
+		"part 2 | enabled: true | sum: 7",
+		// Part 1 ignores don't()
+		"part 1 | enabled: true | sum: 7",
+		"part 1 | enabled: true | sum: 11",
+		// Resetting clears the sum but stays in part 1
+		"part 1 | enabled: true | sum: 0",
+		// Loading a file adds up the whole file, still ignoring don't()
+		"part 1 | enabled: true | sum: 13",
+	}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("got states %q, want %q", states, want)
+	}
+
+	if !strings.Contains(out.String(), "  3 instructions recognised\n") {
+		t.Errorf("expected :load to only report the number of instructions, got\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "error: unknown command \":bogus\"\n"+replHelp) {
+		t.Errorf("expected an unknown command to be followed by the help, got\n%s", out.String())
+	}
+	if strings.Count(out.String(), replHelp) != 2 {
+		t.Errorf("expected the help at the start and after the unknown command only, got\n%s", out.String())
+	}
+}
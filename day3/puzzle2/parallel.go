@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math/big"
+	"regexp"
+	"sync"
+
+	"common/bigsum"
+)
+
+// maxCommandLength is the length of the longest command the regex can match, which is mul(999,999).
+// Every command starting inside a chunk is guaranteed to end within this many bytes of its start.
+const maxCommandLength = len("mul(999,999)")
+
+// enableState tracks the effect a chunk of input has on whether multiplication is enabled.
+type enableState int
+
+const (
+	// unchanged means no do() or don't() command has been seen yet, so the state depends on what came before
+	unchanged enableState = iota
+	enabled
+	disabled
+)
+
+// segmentSummary describes a chunk of the input independently of the chunks before it.
+// Since a mul() command only depends on the most recent do() or don't() before it, a chunk can be summarized
+// by the sum it produces for both possible starting states, along with the state it leaves behind.
+type segmentSummary struct {
+	sumIfStartEnabled  bigsum.Sum
+	sumIfStartDisabled bigsum.Sum
+	finalState         enableState
+}
+
+// summarizeSegment builds the summary of a chunk from the commands matched inside it.
+func summarizeSegment(matches []string) (segmentSummary, error) {
+	var summary segmentSummary
+	for _, match := range matches {
+		if match == doCommand {
+			summary.finalState = enabled
+			continue
+		} else if match == dontCommand {
+			summary.finalState = disabled
+			continue
+		}
+
+		// Before the first do() or don't() of the chunk, the product only counts if the chunk started enabled.
+		// After it, the starting state no longer matters.
+		switch summary.finalState {
+		case unchanged:
+			if err := addMultiplication(&summary.sumIfStartEnabled, match); err != nil {
+				return segmentSummary{}, err
+			}
+		case enabled:
+			if err := addMultiplication(&summary.sumIfStartEnabled, match); err != nil {
+				return segmentSummary{}, err
+			}
+			if err := addMultiplication(&summary.sumIfStartDisabled, match); err != nil {
+				return segmentSummary{}, err
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// combineSegments folds the chunk summaries together from left to right, starting with multiplication enabled.
+func combineSegments(summaries []segmentSummary) *big.Int {
+	var sum bigsum.Sum
+	include := true
+	for _, summary := range summaries {
+		if include {
+			sum.Add(summary.sumIfStartEnabled)
+		} else {
+			sum.Add(summary.sumIfStartDisabled)
+		}
+
+		if summary.finalState != unchanged {
+			include = summary.finalState == enabled
+		}
+	}
+
+	return sum.Value()
+}
+
+// multiplyAndAddParallel produces the same result as running multiplyAndAdd over every match of rx in the input,
+// but splits the input into one chunk per worker and evaluates the chunks on separate goroutines.
+//
+// A chunk owns every command that starts inside of it. Commands can't overlap each other (none of them contain
+// an "m" or "d" after their first character), so searching a little past the end of each chunk finds exactly
+// the commands that would have been found by searching the whole input at once.
+func multiplyAndAddParallel(rx *regexp.Regexp, input string, workers int) (*big.Int, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(input) {
+		workers = len(input)
+	}
+	if workers == 0 {
+		return new(big.Int), nil
+	}
+
+	chunkSize := (len(input) + workers - 1) / workers
+	summaries := make([]segmentSummary, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		start := i * chunkSize
+		end := start + chunkSize
+		if end > len(input) {
+			end = len(input)
+		}
+		if start >= end {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+
+			// Search a little past the end of the chunk so commands crossing the boundary are found,
+			// then drop any matches that actually belong to the next chunk.
+			windowEnd := end + maxCommandLength - 1
+			if windowEnd > len(input) {
+				windowEnd = len(input)
+			}
+			window := input[start:windowEnd]
+			matches := make([]string, 0)
+			for _, loc := range rx.FindAllStringIndex(window, -1) {
+				if loc[0] >= end-start {
+					break
+				}
+				matches = append(matches, window[loc[0]:loc[1]])
+			}
+
+			summaries[i], errs[i] = summarizeSegment(matches)
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return combineSegments(summaries), nil
+}
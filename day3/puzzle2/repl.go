@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"common/bigsum"
+)
+
+const replHelp = `Enter corrupted memory snippets to evaluate them. State is kept between lines.
+Commands:
+  :reset         clear the running sum and enable multiplication again
+  :part 1        ignore do() and don't() commands, like part 1 of the puzzle
+  :part 2        honour do() and don't() commands, like part 2 of the puzzle (the default)
+  :load <file>   evaluate the contents of a file as a single snippet
+  :help          show this message
+  :quit          exit the REPL`
+
+// replSession holds the state that carries over between the lines entered into the REPL.
+type replSession struct {
+	sum           bigsum.Sum
+	include       bool
+	honourToggles bool
+	out           io.Writer
+}
+
+// runREPL reads snippets and commands line by line from in, writing the results of each one to out,
+// until in is exhausted or :quit is entered.
+func runREPL(in io.Reader, out io.Writer) error {
+	session := &replSession{include: true, honourToggles: true, out: out}
+
+	fmt.Fprintln(out, replHelp)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			break
+		}
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, ":") {
+			if err := session.evaluate(strings.NewReader(line), true); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			}
+			continue
+		}
+
+		// Everything starting with a colon is a REPL command rather than a snippet
+		command, argument, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+		argument = strings.TrimSpace(argument)
+
+		switch command {
+		case "quit":
+			return nil
+		case "help":
+			fmt.Fprintln(out, replHelp)
+		case "reset":
+			session.sum = bigsum.Sum{}
+			session.include = true
+			session.printState()
+		case "part":
+			switch argument {
+			case "1":
+				session.honourToggles = false
+			case "2":
+				session.honourToggles = true
+			default:
+				fmt.Fprintf(out, "error: unknown part %q, expected 1 or 2\n", argument)
+				continue
+			}
+			session.printState()
+		case "load":
+			if err := session.load(argument); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			}
+		default:
+			fmt.Fprintf(out, "error: unknown command %q\n", line)
+			fmt.Fprintln(out, replHelp)
+		}
+	}
+
+	fmt.Fprintln(out)
+	return scanner.Err()
+}
+
+// load evaluates the contents of the file as a single snippet. Only the totals are shown, since puzzle inputs
+// contain far too many instructions to list.
+func (s *replSession) load(filename string) error {
+	if filename == "" {
+		return fmt.Errorf("no file provided to load")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return s.evaluate(file, false)
+}
+
+// evaluate runs every instruction recognised in the snippet against the session, optionally listing
+// each instruction and its effect, and then shows the resulting state.
+func (s *replSession) evaluate(snippet io.Reader, listInstructions bool) error {
+	count := 0
+	err := scanCommands(snippet, func(command string) error {
+		count++
+
+		if command == doCommand || command == dontCommand {
+			if s.honourToggles {
+				s.include = command == doCommand
+			}
+			if listInstructions {
+				fmt.Fprintf(s.out, "  %s\n", command)
+			}
+			return nil
+		}
+
+		// Work out the product on its own first, so it can be shown even if it gets skipped
+		var product bigsum.Sum
+		if err := addMultiplication(&product, command); err != nil {
+			return err
+		}
+
+		if s.enabled() {
+			s.sum.Add(product)
+		}
+		if listInstructions {
+			status := "added"
+			if !s.enabled() {
+				status = "skipped"
+			}
+			fmt.Fprintf(s.out, "  %s = %d (%s)\n", command, product.Value(), status)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Fprintln(s.out, "  no instructions recognised")
+	} else if !listInstructions {
+		fmt.Fprintf(s.out, "  %d instructions recognised\n", count)
+	}
+	s.printState()
+	return nil
+}
+
+// enabled reports whether a mul() command entered now would be added to the sum.
+// In part 1 every multiplication counts, no matter which do() or don't() came last.
+func (s *replSession) enabled() bool {
+	return s.include || !s.honourToggles
+}
+
+// printState shows the current part, enabled state and running sum.
+func (s *replSession) printState() {
+	part := 2
+	if !s.honourToggles {
+		part = 1
+	}
+	fmt.Fprintf(s.out, "part %d | enabled: %t | sum: %d\n", part, s.enabled(), s.sum.Value())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"math/big"
+
+	"common/bigsum"
+)
+
+// scanCommands is a hand-written alternative to the regex. It streams the input from the reader and calls
+// emit with every do(), don't() and mul(X,Y) command it recognises, in the order they appear.
+// Any error returned by emit stops the scan and is passed back to the caller.
+func scanCommands(r io.Reader, emit func(command string) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		// No command is longer than maxCommandLength, so that is all the lookahead we ever need.
+		// Peek only returns an error alongside a short read, which still has to be checked for commands.
+		window, err := reader.Peek(maxCommandLength)
+		if len(window) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		length := matchCommand(window)
+		if length == 0 {
+			// Not the start of a command, move on to the next character
+			length = 1
+		} else if err := emit(string(window[:length])); err != nil {
+			return err
+		}
+
+		if _, err := reader.Discard(length); err != nil {
+			return err
+		}
+	}
+}
+
+// matchCommand returns the length of the command at the start of the window, or 0 if there isn't one.
+func matchCommand(window []byte) int {
+	for _, command := range []string{doCommand, dontCommand} {
+		if hasPrefix(window, command) {
+			return len(command)
+		}
+	}
+
+	if !hasPrefix(window, "mul(") {
+		return 0
+	}
+
+	// mul( must be followed by 1-3 digits, a comma, 1-3 digits and a closing paren
+	i := len("mul(")
+	digits := countDigits(window[i:])
+	if digits < 1 || digits > 3 {
+		return 0
+	}
+	i += digits
+
+	if i >= len(window) || window[i] != ',' {
+		return 0
+	}
+	i++
+
+	digits = countDigits(window[i:])
+	if digits < 1 || digits > 3 {
+		return 0
+	}
+	i += digits
+
+	if i >= len(window) || window[i] != ')' {
+		return 0
+	}
+
+	return i + 1
+}
+
+// hasPrefix reports whether the window starts with the provided prefix.
+func hasPrefix(window []byte, prefix string) bool {
+	return len(window) >= len(prefix) && string(window[:len(prefix)]) == prefix
+}
+
+// countDigits returns how many ASCII digits the window starts with.
+func countDigits(window []byte) int {
+	count := 0
+	for count < len(window) && window[count] >= '0' && window[count] <= '9' {
+		count++
+	}
+	return count
+}
+
+// evaluateStream computes the sum of the multiplications in the input using scanCommands instead of the regex.
+// When honourToggles is false, do() and don't() commands are ignored, which gives the answer to part 1.
+func evaluateStream(r io.Reader, honourToggles bool) (*big.Int, error) {
+	var sum bigsum.Sum
+	include := true
+	err := scanCommands(r, func(command string) error {
+		if command == doCommand || command == dontCommand {
+			if honourToggles {
+				include = command == doCommand
+			}
+			return nil
+		}
+
+		if !include {
+			return nil
+		}
+		return addMultiplication(&sum, command)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sum.Value(), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"common/grid"
+	"common/wordsearch"
+)
+
+// runGenerate implements the generate subcommand, which writes a generated word search to a file and prints the
+// answers to both parts of the puzzle for it.
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "seed for the random number generator")
+	rows := flags.Int("rows", 140, "number of rows in the grid")
+	cols := flags.Int("cols", 140, "number of columns in the grid")
+	words := flags.Int("words", 2000, "number of XMAS words to plant")
+	crosses := flags.Int("crosses", 1500, "number of X-MAS crosses to plant")
+	directionNames := flags.String("directions", "", "comma separated directions to plant XMAS in, such as right,down-left (all eight by default)")
+	out := flags.String("out", "generated.txt", "file to write the generated grid to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	directions := make([]grid.Direction, 0)
+	if *directionNames != "" {
+		for _, name := range strings.Split(*directionNames, ",") {
+			dir, err := grid.ParseDirection(name)
+			if err != nil {
+				return err
+			}
+			directions = append(directions, dir)
+		}
+	}
+
+	generated, err := wordsearch.Generate(wordsearch.GeneratorOptions{
+		Seed:       *seed,
+		Rows:       *rows,
+		Cols:       *cols,
+		Words:      *words,
+		Crosses:    *crosses,
+		Directions: directions,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeGrid(*out, generated.Grid); err != nil {
+		return fmt.Errorf("unable to write generated grid due to: %w", err)
+	}
+
+	fmt.Printf("Wrote a %dx%d grid to %s\n", generated.Grid.Rows(), generated.Grid.Cols(), *out)
+	fmt.Printf("Part 1: %d\n", generated.Words)
+	fmt.Printf("Part 2: %d\n", generated.Crosses)
+	return nil
+}
+
+// writeGrid writes the grid to a file with one line per row, in the same format as the input.
+func writeGrid(filename string, g *grid.Grid[byte]) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(file)
+	for row := 0; row < g.Rows(); row++ {
+		cells := g.Row(row)
+		out.Write(cells)
+		out.WriteByte('\n')
+	}
+
+	if err := out.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -1,3 +1,12 @@
 module word-search
 
 go 1.20
+
+require common v0.0.0
+
+require (
+	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace common => ../../common
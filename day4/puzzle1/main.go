@@ -2,199 +2,314 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
-)
 
-type direction int
-
-// Directional constants
-const (
-	ANY direction = iota
-	UP
-	DOWN
-	LEFT
-	RIGHT
-	DIAG_UP_RIGHT
-	DIAG_DOWN_RIGHT
-	DIAG_UP_LEFT
-	DIAG_DOWN_LEFT
+	"common/grid"
+	"common/wordsearch"
 )
 
-var wordToSearch []string = []string{"X", "M", "A", "S"}
+var wordToSearch = []byte("XMAS")
 
 func main() {
 	// Given a matrix of characters, see how many instances of "XMAS"
 	// can be found. Can be horizontal, vertical, diagonal, or backwards.
 
-	// Read the input file into a matrix view
-	matrix, err := parseInputFile("input.txt")
+	wordsFile := flag.String("words", "", "file with a list of words to search for all at once, one per line, instead of XMAS")
+	ragged := flag.Bool("ragged", false, "allow lines of different lengths, treating the missing cells as blanks")
+	graphemes := flag.Bool("graphemes", false, "with -words, split text into grapheme clusters instead of code points")
+	fold := flag.Bool("fold", false, "with -words, match letters regardless of case")
+	normalize := flag.String("normalize", "none", "with -words, the Unicode normalization applied to the grid and words (nfc, nfd or none)")
+	topology := flag.String("topology", "flat", "shape of the grid: flat, torus (words wrap around the edges) or layers (a 3D grid of blank line separated blocks)")
+	renderFormat := flag.String("render", "", "draw the grid with every match highlighted: ansi, svg or png")
+	renderFile := flag.String("out", "", "with -render, the file to draw the grid into instead of standard output")
+	semanticsName := flag.String("semantics", "all", "which matches are counted: all, unique (matches covering the same cells count once), greedy or maximum (matches may not share cells)")
+	workers := flag.Int("workers", 1, "number of goroutines used to search the grid, values above 1 search bands of rows in parallel")
+	flag.Parse()
+
+	if flag.Arg(0) == "generate" {
+		if err := runGenerate(flag.Args()[1:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	render := renderOptions{format: *renderFormat, filename: *renderFile}
+
+	if *wordsFile != "" || *topology != "flat" || *semanticsName != "all" {
+		// The matches are picked in the order the matcher finds them, which searching in bands would change
+		if *workers > 1 {
+			panic(fmt.Errorf("-workers only applies to the search for XMAS, not with -words, -topology or -semantics"))
+		}
+
+		opts, err := textOptions(*graphemes, *fold, *normalize)
+		if err != nil {
+			panic(err)
+		}
+
+		semantics, err := wordsearch.ParseSemantics(*semanticsName)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := searchForWords("input.txt", *wordsFile, *topology, *ragged, opts, semantics, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	// Read the input file into a grid view
+	g, err := parseInputFile("input.txt", *ragged)
 
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("The parsed input matrix is: %s\n", matrix)
+	fmt.Printf("The parsed input grid is %dx%d\n", g.Rows(), g.Cols())
 
-	count := searchForWord(matrix)
+	var count int
+	if *workers > 1 {
+		count = len(findWord(g, *workers))
+	} else {
+		count = searchForWord(g)
+	}
 
-	fmt.Printf("The total number of occurences of the word is: %d", count)
-}
+	fmt.Printf("The total number of occurences of the word is: %d\n", count)
 
-// searchForWord searches the provided matrix for all occurrences of a word.
-func searchForWord(matrix [][]string) int {
-	xmasCount := 0
-	for i, row := range matrix {
-		for j, char := range row {
-			// Search for X
-			if char == wordToSearch[0] {
-				// Add matches to total
-				xmasCount += search(ANY, 1, i, j, matrix)
-			}
+	if render.format != "" {
+		if err := renderWord(g, render); err != nil {
+			panic(err)
 		}
 	}
+}
+
+// textOptions converts the -graphemes, -fold and -normalize flags to the options the grid and words are split and
+// compared with.
+func textOptions(graphemes bool, fold bool, normalize string) (wordsearch.TextOptions, error) {
+	normalization, err := wordsearch.ParseNormalization(normalize)
+	if err != nil {
+		return wordsearch.TextOptions{}, err
+	}
+
+	opts := wordsearch.TextOptions{Normalization: normalization, FoldCase: fold}
+	if graphemes {
+		opts.Segmentation = wordsearch.SplitGraphemes
+	}
+	return opts, nil
+}
+
+// renderOptions holds where and how to draw the grid once it has been searched. An empty format means the grid
+// isn't drawn at all.
+type renderOptions struct {
+	format   string
+	filename string
+}
+
+// renderWord draws the grid with every occurrence of the word highlighted.
+func renderWord(g *grid.Grid[byte], render renderOptions) error {
+	matcher, err := wordsearch.NewByteMatcher(string(wordToSearch))
+	if err != nil {
+		return err
+	}
+
+	// The renderer works on cells of text, so turn each byte into a string of its own
+	text := grid.New[string](g.Rows(), g.Cols())
+	g.ForEach(func(p grid.Point, char byte) {
+		if char != 0 {
+			text.Set(p, string(char))
+		}
+	})
+
+	return wordsearch.RenderFile(render.filename, render.format, text, wordsearch.Highlights(text, matcher.FindAll(g)))
+}
+
+// searchForWord searches the provided grid for all occurrences of a word.
+func searchForWord(g *grid.Grid[byte]) int {
+	xmasCount := 0
+	searchBand(g.Band(0, g.Rows(), 0), func(grid.Point, grid.Direction) {
+		xmasCount++
+	})
 
 	return xmasCount
 }
 
-// search searches recursively for all remaining letters of the string to find in the provided direction.
-// the value returned is the total number of matches it found.
-func search(dir direction, charIndex int, row int, col int, matrix [][]string) int {
-	// This means we've found a match!
-	if charIndex == len(wordToSearch) {
-		return 1
+// findWord returns the position and direction of every occurrence of the word, row by row. With more than one
+// worker, the grid is split into bands of rows that are searched in parallel by that many goroutines. Each band
+// can see the len(word)-1 rows either side of it, which is as far as a word starting in the band can reach, and
+// only reports the words starting in its own rows, so each word is found exactly once.
+func findWord(g *grid.Grid[byte], workers int) []wordsearch.Match {
+	find := func(b grid.Band[byte]) []wordsearch.Match {
+		matches := make([]wordsearch.Match, 0)
+		searchBand(b, func(start grid.Point, dir grid.Direction) {
+			matches = append(matches, wordsearch.Match{Start: b.ToGrid(start), Dir: dir, Length: len(wordToSearch)})
+		})
+		return matches
 	}
 
-	if dir == ANY {
-		return search(UP, charIndex, row, col, matrix) +
-			search(DOWN, charIndex, row, col, matrix) +
-			search(LEFT, charIndex, row, col, matrix) +
-			search(RIGHT, charIndex, row, col, matrix) +
-			search(DIAG_DOWN_LEFT, charIndex, row, col, matrix) +
-			search(DIAG_DOWN_RIGHT, charIndex, row, col, matrix) +
-			search(DIAG_UP_LEFT, charIndex, row, col, matrix) +
-			search(DIAG_UP_RIGHT, charIndex, row, col, matrix)
+	if workers <= 1 {
+		return find(g.Band(0, g.Rows(), 0))
 	}
+	return grid.SearchBands(g, workers, len(wordToSearch)-1, find)
+}
 
-	if dir == UP {
-		if row < 1 {
-			// Can't go up any more, done
-			return 0
-		}
-		if matrix[row-1][col] == wordToSearch[charIndex] {
-			return search(UP, charIndex+1, row-1, col, matrix)
+// searchBand searches the rows a band owns for the word, calling found with the start and direction of each one.
+// Every X is a potential start of the word, so from each one we walk outwards in all eight directions.
+func searchBand(b grid.Band[byte], found func(start grid.Point, dir grid.Direction)) {
+	for row := b.First; row < b.End; row++ {
+		cells := b.Grid.Row(row)
+		for col, char := range cells {
+			// Search for X
+			if char != wordToSearch[0] {
+				continue
+			}
+
+			start := grid.Point{Row: row, Col: col}
+			for _, dir := range grid.All {
+				if matchesInDirection(b.Grid, start, dir) {
+					found(start, dir)
+				}
+			}
 		}
-		return 0
 	}
+}
 
-	if dir == DOWN {
-		if row == len(matrix)-1 {
-			// Can't go down any more
-			return 0
+// searchForWords searches the grid in the input file for every word listed in the words file in a single pass,
+// using an Aho-Corasick automaton, and prints each match with its position and direction. Without a words file,
+// it searches for XMAS. Both files are treated as Unicode text, split into cells and compared according to the
+// options, and the grid is searched as the provided topology. Only the matches counted under the semantics are
+// printed. Flat and torus grids can then be drawn with those matches highlighted.
+func searchForWords(inputFile string, wordsFile string, topology string, ragged bool, opts wordsearch.TextOptions,
+	semantics wordsearch.Semantics, render renderOptions) error {
+	words := []string{string(wordToSearch)}
+	if wordsFile != "" {
+		var err error
+		words, err = parseWordsFile(wordsFile)
+		if err != nil {
+			return err
 		}
-		if matrix[row+1][col] == wordToSearch[charIndex] {
-			return search(DOWN, charIndex+1, row+1, col, matrix)
-		}
-		return 0
 	}
 
-	if dir == LEFT {
-		if col < 1 {
-			// Can't go left
-			return 0
-		}
-		if matrix[row][col-1] == wordToSearch[charIndex] {
-			return search(LEFT, charIndex+1, row, col-1, matrix)
-		}
-		return 0
+	matcher, err := wordsearch.NewTextMatcher(words, opts)
+	if err != nil {
+		return fmt.Errorf("unable to build matcher for %s due to: %w", wordsFile, err)
 	}
 
-	if dir == RIGHT {
-		// ci = 1, row = 0, col = 0,
-		if col == len(matrix[row])-1 {
-			// Can't go right
-			return 0
-		}
-		if matrix[row][col+1] == wordToSearch[charIndex] {
-			return search(RIGHT, charIndex+1, row, col+1, matrix)
+	loadOpts := grid.LoadOptions[string]{Ragged: ragged}
+
+	var matches []wordsearch.Match
+	// flat holds the grid when it is flat or a torus, which are the only grids that can be rendered
+	var flat *grid.Grid[string]
+	switch topology {
+	case "flat", "torus":
+		g, err := grid.LoadFile(inputFile, opts.Split, loadOpts)
+		if err != nil {
+			return err
 		}
-		return 0
-	}
+		fmt.Printf("The parsed input grid is %dx%d\n", g.Rows(), g.Cols())
 
-	if dir == DIAG_UP_RIGHT {
-		if row < 1 || col == len(matrix[row])-1 {
-			// Can't go up/right any more, done
-			return 0
+		if topology == "flat" {
+			matches, err = wordsearch.Select(matcher.FindAll(g), semantics, nil)
+		} else {
+			matches, err = wordsearch.Select(matcher.FindAllTorus(g), semantics, g.Wrap)
 		}
-		if matrix[row-1][col+1] == wordToSearch[charIndex] {
-			return search(DIAG_UP_RIGHT, charIndex+1, row-1, col+1, matrix)
+		if err != nil {
+			return err
+		}
+		flat = g
+	case "layers":
+		if render.format != "" {
+			return fmt.Errorf("unable to render a layered grid, only flat and torus grids can be rendered")
 		}
-		return 0
-	}
 
-	if dir == DIAG_UP_LEFT {
-		if row < 1 || col < 1 {
-			// Can't go up/left any more, done
-			return 0
+		g, err := grid.LoadLayersFile(inputFile, opts.Split, loadOpts)
+		if err != nil {
+			return err
 		}
-		if matrix[row-1][col-1] == wordToSearch[charIndex] {
-			return search(DIAG_UP_LEFT, charIndex+1, row-1, col-1, matrix)
+		fmt.Printf("The parsed input grid is %dx%dx%d\n", g.Layers(), g.Rows(), g.Cols())
+
+		matches, err = wordsearch.Select(matcher.FindAll3D(g), semantics, nil)
+		if err != nil {
+			return err
 		}
-		return 0
+	default:
+		return fmt.Errorf("unknown topology %q, expected flat, torus or layers", topology)
 	}
 
-	if dir == DIAG_DOWN_RIGHT {
-		if row == len(matrix)-1 || col == len(matrix[row])-1 {
-			// Can't go down/right any more, done
-			return 0
-		}
-		if matrix[row+1][col+1] == wordToSearch[charIndex] {
-			return search(DIAG_DOWN_RIGHT, charIndex+1, row+1, col+1, matrix)
+	for _, match := range matches {
+		if topology == "layers" {
+			fmt.Printf("%s at %s reading %s\n", words[match.Word], match.Start3(), match.Dir3())
+		} else {
+			fmt.Printf("%s at %s reading %s\n", words[match.Word], match.Start, match.Dir)
 		}
-		return 0
 	}
 
-	if dir == DIAG_DOWN_LEFT {
-		if row == len(matrix)-1 || col < 1 {
-			// Can't go down/left any more, done
-			return 0
-		}
-		if matrix[row+1][col-1] == wordToSearch[charIndex] {
-			return search(DIAG_DOWN_LEFT, charIndex+1, row+1, col-1, matrix)
+	fmt.Printf("Found %d matches of %d words\n", len(matches), len(words))
+
+	// Draw the grid after the matches are listed, so the picture isn't lost among them
+	if render.format != "" {
+		return wordsearch.RenderFile(render.filename, render.format, flat, wordsearch.Highlights(flat, matches))
+	}
+	return nil
+}
+
+// matchesInDirection checks whether the remaining letters of the word follow the start point when walking
+// in the provided direction, one (dr, dc) step at a time.
+func matchesInDirection(g *grid.Grid[byte], start grid.Point, dir grid.Direction) bool {
+	// If the last letter would be off the edge of the grid, there is no room for the word at all.
+	// Otherwise every letter in between is on the grid too, so they can be read without any more bounds checks.
+	if !g.InBounds(start.Step(dir, len(wordToSearch)-1)) {
+		return false
+	}
+
+	p := start
+	for i := 1; i < len(wordToSearch); i++ {
+		p = p.Add(dir)
+		if g.At(p) != wordToSearch[i] {
+			return false
 		}
-		return 0
 	}
 
-	panic(fmt.Sprintf("Unrecognized direction value received: %d", dir))
+	return true
 }
 
-// parseInputFile parses the input file line by line, splitting each line into
-// a slice of characters. Any errors encountered are returned.
-func parseInputFile(filename string) ([][]string, error) {
+// parseInputFile parses the input file line by line into a grid with one byte per character.
+// Unless ragged is set, every line must be the same length, and the first line that isn't is reported in the error.
+// In a ragged grid, the cells missing from the end of shorter lines are blanks that never match a letter.
+func parseInputFile(filename string, ragged bool) (*grid.Grid[byte], error) {
+	return grid.LoadFile(filename, func(line string) []byte {
+		fmt.Printf("Parsed line: %s\n", line)
+
+		// Each character of the line becomes a cell of the grid
+		return grid.Bytes(line)
+	}, grid.LoadOptions[byte]{Ragged: ragged})
+}
+
+// parseWordsFile reads a list of words, one per line. Blank lines are skipped, and so are words that were already
+// read, so each occurrence of a word in the grid is only counted once.
+func parseWordsFile(filename string) ([]string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	rows := make([][]string, 0)
+	words := make([]string, 0)
+	seen := make(map[string]bool)
 
-	// Using bufio to read the input file line by line
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		line := scanner.Text()
-		fmt.Printf("Parsed line: %s\n", line)
-
-		// Split each line into individual characters
-		characters := strings.Split(line, "")
-
-		rows = append(rows, characters)
+		word := strings.TrimSpace(scanner.Text())
+		if word != "" && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input file due to : %w", err)
+		return nil, fmt.Errorf("error reading words file due to : %w", err)
 	}
 
-	return rows, nil
+	return words, nil
 }
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+
+	"common/grid"
+	"common/wordsearch"
+)
+
+type direction int
+
+// Directional constants
+const (
+	ANY direction = iota
+	UP
+	DOWN
+	LEFT
+	RIGHT
+	DIAG_UP_RIGHT
+	DIAG_DOWN_RIGHT
+	DIAG_UP_LEFT
+	DIAG_DOWN_LEFT
+)
+
+var recursiveWord = []string{"X", "M", "A", "S"}
+
+// searchForWordRecursive is the original recursive implementation of searchForWord, kept as a reference for
+// the results and speed of the iterative version.
+func searchForWordRecursive(matrix [][]string) int {
+	xmasCount := 0
+	for i, row := range matrix {
+		for j, char := range row {
+			// Search for X
+			if char == recursiveWord[0] {
+				// Add matches to total
+				xmasCount += searchRecursive(ANY, 1, i, j, matrix)
+			}
+		}
+	}
+
+	return xmasCount
+}
+
+// searchRecursive searches recursively for all remaining letters of the string to find in the provided direction.
+// the value returned is the total number of matches it found.
+func searchRecursive(dir direction, charIndex int, row int, col int, matrix [][]string) int {
+	// This means we've found a match!
+	if charIndex == len(recursiveWord) {
+		return 1
+	}
+
+	if dir == ANY {
+		return searchRecursive(UP, charIndex, row, col, matrix) +
+			searchRecursive(DOWN, charIndex, row, col, matrix) +
+			searchRecursive(LEFT, charIndex, row, col, matrix) +
+			searchRecursive(RIGHT, charIndex, row, col, matrix) +
+			searchRecursive(DIAG_DOWN_LEFT, charIndex, row, col, matrix) +
+			searchRecursive(DIAG_DOWN_RIGHT, charIndex, row, col, matrix) +
+			searchRecursive(DIAG_UP_LEFT, charIndex, row, col, matrix) +
+			searchRecursive(DIAG_UP_RIGHT, charIndex, row, col, matrix)
+	}
+
+	if dir == UP {
+		if row < 1 {
+			// Can't go up any more, done
+			return 0
+		}
+		if matrix[row-1][col] == recursiveWord[charIndex] {
+			return searchRecursive(UP, charIndex+1, row-1, col, matrix)
+		}
+		return 0
+	}
+
+	if dir == DOWN {
+		if row == len(matrix)-1 {
+			// Can't go down any more
+			return 0
+		}
+		if matrix[row+1][col] == recursiveWord[charIndex] {
+			return searchRecursive(DOWN, charIndex+1, row+1, col, matrix)
+		}
+		return 0
+	}
+
+	if dir == LEFT {
+		if col < 1 {
+			// Can't go left
+			return 0
+		}
+		if matrix[row][col-1] == recursiveWord[charIndex] {
+			return searchRecursive(LEFT, charIndex+1, row, col-1, matrix)
+		}
+		return 0
+	}
+
+	if dir == RIGHT {
+		// ci = 1, row = 0, col = 0,
+		if col == len(matrix[row])-1 {
+			// Can't go right
+			return 0
+		}
+		if matrix[row][col+1] == recursiveWord[charIndex] {
+			return searchRecursive(RIGHT, charIndex+1, row, col+1, matrix)
+		}
+		return 0
+	}
+
+	if dir == DIAG_UP_RIGHT {
+		if row < 1 || col == len(matrix[row])-1 {
+			// Can't go up/right any more, done
+			return 0
+		}
+		if matrix[row-1][col+1] == recursiveWord[charIndex] {
+			return searchRecursive(DIAG_UP_RIGHT, charIndex+1, row-1, col+1, matrix)
+		}
+		return 0
+	}
+
+	if dir == DIAG_UP_LEFT {
+		if row < 1 || col < 1 {
+			// Can't go up/left any more, done
+			return 0
+		}
+		if matrix[row-1][col-1] == recursiveWord[charIndex] {
+			return searchRecursive(DIAG_UP_LEFT, charIndex+1, row-1, col-1, matrix)
+		}
+		return 0
+	}
+
+	if dir == DIAG_DOWN_RIGHT {
+		if row == len(matrix)-1 || col == len(matrix[row])-1 {
+			// Can't go down/right any more, done
+			return 0
+		}
+		if matrix[row+1][col+1] == recursiveWord[charIndex] {
+			return searchRecursive(DIAG_DOWN_RIGHT, charIndex+1, row+1, col+1, matrix)
+		}
+		return 0
+	}
+
+	if dir == DIAG_DOWN_LEFT {
+		if row == len(matrix)-1 || col < 1 {
+			// Can't go down/left any more, done
+			return 0
+		}
+		if matrix[row+1][col-1] == recursiveWord[charIndex] {
+			return searchRecursive(DIAG_DOWN_LEFT, charIndex+1, row+1, col-1, matrix)
+		}
+		return 0
+	}
+
+	panic(fmt.Sprintf("Unrecognized direction value received: %d", dir))
+}
+
+// toMatrix converts a grid into the [][]string layout used by the recursive implementation.
+func toMatrix(g *grid.Grid[byte]) [][]string {
+	matrix := make([][]string, g.Rows())
+	for row := range matrix {
+		matrix[row] = make([]string, g.Cols())
+		cells := g.Row(row)
+		for col, char := range cells {
+			matrix[row][col] = string(char)
+		}
+	}
+	return matrix
+}
+
+// randomGrid builds a square grid of random X, M, A and S characters.
+func randomGrid(size int, seed int64) *grid.Grid[byte] {
+	rng := rand.New(rand.NewSource(seed))
+	g := grid.New[byte](size, size)
+	for row := 0; row < size; row++ {
+		cells := g.Row(row)
+		for col := range cells {
+			cells[col] = wordToSearch[rng.Intn(len(wordToSearch))]
+		}
+	}
+	return g
+}
+
+func TestSearchForWord(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     int
+	}{
+		{"input_test.txt", 18},
+		{"input_test2.txt", 1},
+		{"input.txt", 2685},
+	}
+
+	for _, test := range tests {
+		g, err := parseInputFile(test.filename, false)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %v", test.filename, err)
+		}
+
+		if got := searchForWord(g); got != test.want {
+			t.Errorf("%s: got %d occurrences, want %d", test.filename, got, test.want)
+		}
+		if got := searchForWordRecursive(toMatrix(g)); got != test.want {
+			t.Errorf("%s: recursive search got %d occurrences, want %d", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestSearchForWordMatchesRecursive(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		g := randomGrid(50, seed)
+		if got, want := searchForWord(g), searchForWordRecursive(toMatrix(g)); got != want {
+			t.Errorf("seed %d: iterative search got %d occurrences, recursive got %d", seed, got, want)
+		}
+	}
+}
+
+func TestParseInputFileRagged(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ragged.txt")
+	if err := os.WriteFile(filename, []byte("XMASX\nMM\nAAA\nSSSS\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := parseInputFile(filename, false)
+	var raggedErr *grid.RaggedLineError
+	if !errors.As(err, &raggedErr) {
+		t.Fatalf("expected a RaggedLineError, got %v", err)
+	}
+	if raggedErr.Line != 2 || raggedErr.Text != "MM" {
+		t.Errorf("expected line 2 \"MM\" to be reported, got line %d %q", raggedErr.Line, raggedErr.Text)
+	}
+
+	g, err := parseInputFile(filename, true)
+	if err != nil {
+		t.Fatalf("unable to parse ragged grid: %v", err)
+	}
+	if g.Rows() != 4 || g.Cols() != 5 {
+		t.Errorf("expected a 4x5 grid, got %dx%d", g.Rows(), g.Cols())
+	}
+
+	// XMAS reads across the first row, down the first column and down the diagonal,
+	// and the blanks must not get in the way
+	if got := searchForWord(g); got != 3 {
+		t.Errorf("got %d occurrences in the ragged grid, want 3", got)
+	}
+}
+
+func TestMatcherAgreesWithSearchForWord(t *testing.T) {
+	matcher, err := wordsearch.NewByteMatcher(string(wordToSearch))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range []string{"input_test.txt", "input_test2.txt", "input.txt"} {
+		g, err := parseInputFile(filename, false)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %v", filename, err)
+		}
+
+		if got, want := len(matcher.FindAll(g)), searchForWord(g); got != want {
+			t.Errorf("%s: matcher found %d occurrences, searchForWord found %d", filename, got, want)
+		}
+	}
+}
+
+func TestMatcherFindsManyWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+	}{
+		// MAS and AS end inside XMAS, so they are only found through the fail links and the outputs they pass on
+		{"suffixes", []string{"XMAS", "MAS", "AS"}},
+		// XM and XMA are prefixes of XMAS, and SAM is XMAS backwards without the X
+		{"prefixes", []string{"XM", "XMA", "XMAS", "SAM"}},
+		{"single letter", []string{"S", "XMAS"}},
+	}
+
+	for _, filename := range []string{"input_test.txt", "input_test2.txt"} {
+		g, err := parseInputFile(filename, false)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %v", filename, err)
+		}
+
+		for _, test := range tests {
+			matcher, err := wordsearch.NewByteMatcher(test.words...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := matchKeys(test.words, matcher.FindAll(g))
+			want := naiveSearch(g, test.words)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s: matcher found %v, want %v", filename, test.name, got, want)
+			}
+		}
+	}
+
+	// A single letter has no direction, so each occurrence is only reported once, reading to the right
+	g, err := parseInputFile("input_test.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, err := wordsearch.NewByteMatcher("S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := matcher.FindAll(g)
+	letters := 0
+	g.ForEach(func(_ grid.Point, char byte) {
+		if char == 'S' {
+			letters++
+		}
+	})
+	if len(matches) != letters {
+		t.Errorf("expected one match for each of the %d S cells, got %d", letters, len(matches))
+	}
+	for _, match := range matches {
+		if match.Dir != grid.Right {
+			t.Errorf("expected the single letter at %s to read to the right, got %s", match.Start, match.Dir)
+		}
+	}
+}
+
+// matchKeys describes each match as its word, start and direction, sorted so they can be compared.
+func matchKeys(words []string, matches []wordsearch.Match) []string {
+	keys := make([]string, len(matches))
+	for i, match := range matches {
+		keys[i] = fmt.Sprintf("%s %s %s", words[match.Word], match.Start, match.Dir)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// naiveSearch checks every word from every cell in every direction, one letter at a time. One-letter words are
+// only counted reading to the right, the same as the matcher.
+func naiveSearch(g *grid.Grid[byte], words []string) []string {
+	keys := make([]string, 0)
+	for _, word := range words {
+		g.ForEach(func(start grid.Point, _ byte) {
+			for _, dir := range grid.All {
+				if len(word) == 1 && dir != grid.Right {
+					continue
+				}
+				found := true
+				for i := range word {
+					if char, ok := g.Get(start.Step(dir, i)); !ok || char != word[i] {
+						found = false
+						break
+					}
+				}
+				if found {
+					keys = append(keys, fmt.Sprintf("%s %s %s", word, start, dir))
+				}
+			}
+		})
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestParseWordsFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte("XMAS\n\n  MAS \nXMAS\n   \nAS\nMAS\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := parseWordsFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"XMAS", "MAS", "AS"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("got %q, want %q", words, want)
+	}
+}
+
+func TestTextOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		graphemes bool
+		fold      bool
+		normalize string
+		grid      string
+		word      string
+		// want is the number of matches, or -1 if the flags should be rejected
+		want int
+	}{
+		{name: "defaults", normalize: "none", grid: "xmas", word: "XMAS", want: 0},
+		{name: "fold", fold: true, normalize: "none", grid: "xmas", word: "XMAS", want: 1},
+		{name: "graphemes", graphemes: true, normalize: "none", grid: "cafe\u0301", word: "fe", want: 0},
+		{name: "characters", normalize: "none", grid: "cafe\u0301", word: "fe", want: 1},
+		{name: "nfc", graphemes: true, normalize: "nfc", grid: "cafe\u0301", word: "caf\u00e9", want: 1},
+		{name: "nfd", normalize: "NFD", grid: "caf\u00e9", word: "cafe\u0301", want: 1},
+		{name: "unknown normalization", normalize: "nfkd", want: -1},
+	}
+	for _, test := range tests {
+		opts, err := textOptions(test.graphemes, test.fold, test.normalize)
+		if test.want == -1 {
+			if err == nil {
+				t.Errorf("%s: expected -normalize %s to be rejected", test.name, test.normalize)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		g, err := grid.FromRows([][]string{opts.Split(test.grid)})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		matcher, err := wordsearch.NewTextMatcher([]string{test.word}, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := len(matcher.FindAll(g)); got != test.want {
+			t.Errorf("%s: got %d matches, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func BenchmarkSearchForWord(b *testing.B) {
+	for _, size := range []int{140, 1000} {
+		g := randomGrid(size, 1)
+		matrix := toMatrix(g)
+
+		b.Run(fmt.Sprintf("recursive-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				searchForWordRecursive(matrix)
+			}
+		})
+
+		b.Run(fmt.Sprintf("iterative-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				searchForWord(g)
+			}
+		})
+
+		b.Run(fmt.Sprintf("parallel-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				findWord(g, runtime.GOMAXPROCS(0))
+			}
+		})
+	}
+}
+
+func TestGeneratedGrid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts wordsearch.GeneratorOptions
+	}{
+		{"all directions", wordsearch.GeneratorOptions{Seed: 1, Rows: 140, Cols: 140, Words: 2000, Crosses: 1500}},
+		{"one direction", wordsearch.GeneratorOptions{Seed: 2, Rows: 60, Cols: 80, Words: 300, Crosses: 100,
+			Directions: []grid.Direction{grid.DownLeft}}},
+		{"crowded", wordsearch.GeneratorOptions{Seed: 3, Rows: 12, Cols: 12, Words: 25, Crosses: 8}},
+		{"no words", wordsearch.GeneratorOptions{Seed: 4, Rows: 20, Cols: 20}},
+	}
+
+	for _, test := range tests {
+		generated, err := wordsearch.Generate(test.opts)
+		if err != nil {
+			t.Fatalf("%s: unable to generate grid: %v", test.name, err)
+		}
+
+		if got := searchForWord(generated.Grid); got != generated.Words {
+			t.Errorf("%s: got %d occurrences, want %d", test.name, got, generated.Words)
+		}
+		if got := searchForWordRecursive(toMatrix(generated.Grid)); got != generated.Words {
+			t.Errorf("%s: recursive search got %d occurrences, want %d", test.name, got, generated.Words)
+		}
+	}
+}
+
+func TestGenerateRejectsBadOptions(t *testing.T) {
+	tests := []wordsearch.GeneratorOptions{
+		{Rows: 0, Cols: 10},
+		{Rows: 10, Cols: 10, Words: -1},
+		{Rows: 10, Cols: 10, Words: 1, Directions: []grid.Direction{{DRow: 2, DCol: 0}}},
+		// There isn't room for this many words in such a small grid
+		{Rows: 4, Cols: 4, Words: 100},
+	}
+
+	for _, opts := range tests {
+		if _, err := wordsearch.Generate(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestFindWordParallelMatchesSequential(t *testing.T) {
+	generated, err := wordsearch.Generate(wordsearch.GeneratorOptions{Seed: 5, Rows: 300, Cols: 200, Words: 4000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grids := map[string]*grid.Grid[byte]{
+		"generated": generated.Grid,
+		"random":    randomGrid(97, 6),
+		// Fewer rows than workers, and fewer rows than the length of the word
+		"short": randomGrid(2, 7),
+	}
+
+	for name, g := range grids {
+		want := findWord(g, 1)
+		if len(want) != searchForWord(g) {
+			t.Fatalf("%s: sequential search found %d matches, searchForWord counted %d", name, len(want), searchForWord(g))
+		}
+
+		for _, workers := range []int{2, 3, 8, 64} {
+			got := findWord(g, workers)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %d workers found %d matches, which don't match the %d found sequentially",
+					name, workers, len(got), len(want))
+			}
+		}
+	}
+}
@@ -1,145 +1,121 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"os"
-	"strings"
-)
 
-type direction int
-
-// Directional constants
-const (
-	ANY direction = iota
-	UP
-	DOWN
-	LEFT
-	RIGHT
-	DIAG_UP_RIGHT
-	DIAG_DOWN_RIGHT
-	DIAG_UP_LEFT
-	DIAG_DOWN_LEFT
+	"common/grid"
+	"common/wordsearch"
 )
 
 func main() {
 	// Given a matrix of characters, see how many instances of "MAS" arranged in an "X" pattern
 	// can be found. Can be written forwards or backwards.
 
-	// Read the input file into a matrix view
-	matrix, err := parseInputFile("input.txt")
+	ragged := flag.Bool("ragged", false, "allow lines of different lengths, treating the missing cells as blanks")
+	renderFormat := flag.String("render", "", "draw the grid with every x-mas highlighted: ansi, svg or png")
+	renderFile := flag.String("out", "", "with -render, the file to draw the grid into instead of standard output")
+	workers := flag.Int("workers", 1, "number of goroutines used to search the grid, values above 1 search bands of rows in parallel")
+	flag.Parse()
+
+	// Read the input file into a grid view
+	g, err := parseInputFile("input.txt", *ragged)
 
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("The parsed input matrix is: %s\n", matrix)
+	fmt.Printf("The parsed input grid is %dx%d\n", g.Rows(), g.Cols())
 
 	// Crawl the matrix for x-mas instances
-	count := searchForXmas(matrix)
+	centers := findXmas(g, *workers)
 
-	fmt.Printf("The total number of x-mas occurences is: %d", count)
-}
+	fmt.Printf("The total number of x-mas occurences is: %d\n", len(centers))
 
-// searchForXmas searches the provided matrix for the center of an X-MAS (an A character),
-// then searches the surrounding area to check if it is truly an X-MAS
-func searchForXmas(matrix [][]string) int {
-	xmasCount := 0
-	for i, row := range matrix {
-		for j, char := range row {
-			// Search for A
-			if char == "A" && isXmas(i, j, matrix) {
-				xmasCount++
-			}
+	if *renderFormat != "" {
+		if err := wordsearch.RenderFile(*renderFile, *renderFormat, g, xmasHighlights(centers)); err != nil {
+			panic(err)
 		}
 	}
-
-	return xmasCount
 }
 
-// isXmas takes a coordinate of an "A" and searches diagonally around it for 2 M/S characters that qualify it
-// as an X-MAS instance. Short circuits if on an edge or if one MAS is not found.
-func isXmas(row int, col int, matrix [][]string) bool {
-	// Short circuit - if we are at any edge of the matrix, we cannot have an x-mas
-	if row < 1 || col < 1 || row == len(matrix)-1 || col == len(matrix[row])-1 {
-		return false
-	}
-
-	oneMas := (search(DIAG_UP_LEFT, "M", row, col, matrix) && search(DIAG_DOWN_RIGHT, "S", row, col, matrix)) ||
-		(search(DIAG_UP_LEFT, "S", row, col, matrix) && search(DIAG_DOWN_RIGHT, "M", row, col, matrix))
+// searchForXmas counts the X-MAS instances in the provided grid.
+func searchForXmas(g *grid.Grid[string]) int {
+	return len(findXmas(g, 1))
+}
 
-	if !oneMas {
-		return false
+// findXmas returns the center of every X-MAS in the provided grid, row by row. With more than one worker, the grid
+// is split into bands of rows that are searched in parallel by that many goroutines. Each band can see the row
+// either side of it, which holds the corners of the X-MAS centered on its edges, and only reports the centers in
+// its own rows, so each X-MAS is found exactly once.
+func findXmas(g *grid.Grid[string], workers int) []grid.Point {
+	if workers <= 1 {
+		return findXmasInBand(g.Band(0, g.Rows(), 0))
 	}
-
-	return (search(DIAG_UP_RIGHT, "M", row, col, matrix) && search(DIAG_DOWN_LEFT, "S", row, col, matrix)) ||
-		(search(DIAG_UP_RIGHT, "S", row, col, matrix) && search(DIAG_DOWN_LEFT, "M", row, col, matrix))
+	return grid.SearchBands(g, workers, 1, findXmasInBand)
 }
 
-// search searches in the specified direction in the provided matrix from the given coordinate for the
-// provided character.
-func search(dir direction, char string, row int, col int, matrix [][]string) bool {
-	if dir == DIAG_UP_RIGHT {
-		if row < 1 || col == len(matrix[row])-1 {
-			// Can't go up/right any more, done
-			return false
+// findXmasInBand searches the rows a band owns for the center of an X-MAS (an A character),
+// then searches the surrounding area to check if it is truly an X-MAS.
+func findXmasInBand(b grid.Band[string]) []grid.Point {
+	centers := make([]grid.Point, 0)
+	for row := b.First; row < b.End; row++ {
+		cells := b.Grid.Row(row)
+		for col, char := range cells {
+			// Search for A
+			p := grid.Point{Row: row, Col: col}
+			if char == "A" && isXmas(p, b.Grid) {
+				centers = append(centers, b.ToGrid(p))
+			}
 		}
-		return matrix[row-1][col+1] == char
 	}
 
-	if dir == DIAG_UP_LEFT {
-		if row < 1 || col < 1 {
-			// Can't go up/left any more, done
-			return false
-		}
-		return matrix[row-1][col-1] == char
-	}
+	return centers
+}
 
-	if dir == DIAG_DOWN_RIGHT {
-		if row == len(matrix)-1 || col == len(matrix[row])-1 {
-			// Can't go down/right any more, done
-			return false
+// xmasHighlights turns the center of each X-MAS into the five cells to highlight when rendering it: the center
+// and its four diagonal neighbours.
+func xmasHighlights(centers []grid.Point) [][]grid.Point {
+	highlights := make([][]grid.Point, len(centers))
+	for i, center := range centers {
+		cells := []grid.Point{center}
+		for _, dir := range grid.Diagonal {
+			cells = append(cells, center.Add(dir))
 		}
-		return matrix[row+1][col+1] == char
+		highlights[i] = cells
 	}
+	return highlights
+}
 
-	if dir == DIAG_DOWN_LEFT {
-		if row == len(matrix)-1 || col < 1 {
-			// Can't go down/left any more, done
-			return false
-		}
-		return matrix[row+1][col-1] == char
+// isXmas takes a coordinate of an "A" and searches diagonally around it for 2 M/S characters that qualify it
+// as an X-MAS instance. Short circuits if one MAS is not found.
+func isXmas(center grid.Point, g *grid.Grid[string]) bool {
+	oneMas := (search(grid.UpLeft, "M", center, g) && search(grid.DownRight, "S", center, g)) ||
+		(search(grid.UpLeft, "S", center, g) && search(grid.DownRight, "M", center, g))
+
+	if !oneMas {
+		return false
 	}
 
-	panic(fmt.Sprintf("Unrecognized direction value received: %d", dir))
+	return (search(grid.UpRight, "M", center, g) && search(grid.DownLeft, "S", center, g)) ||
+		(search(grid.UpRight, "S", center, g) && search(grid.DownLeft, "M", center, g))
 }
 
-// parseInputFile parses the input file line by line, splitting each line into
-// a slice of characters. Any errors encountered are returned.
-func parseInputFile(filename string) ([][]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	rows := make([][]string, 0)
+// search checks whether the neighbour of the given point in the specified direction is the provided character.
+// Neighbours outside the grid (when the point is on an edge) never match.
+func search(dir grid.Direction, char string, p grid.Point, g *grid.Grid[string]) bool {
+	neighbour, ok := g.Get(p.Add(dir))
+	return ok && neighbour == char
+}
 
-	// Using bufio to read the input file line by line
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+// parseInputFile parses the input file line by line into a grid with one character per cell.
+// Unless ragged is set, every line must be the same length, and the first line that isn't is reported in the error.
+// In a ragged grid, the cells missing from the end of shorter lines are empty strings that never match a letter.
+func parseInputFile(filename string, ragged bool) (*grid.Grid[string], error) {
+	return grid.LoadFile(filename, func(line string) []string {
 		fmt.Printf("Parsed line: %s\n", line)
 
 		// Split each line into individual characters
-		characters := strings.Split(line, "")
-
-		rows = append(rows, characters)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input file due to : %w", err)
-	}
-
-	return rows, nil
+		return grid.Characters(line)
+	}, grid.LoadOptions[string]{Ragged: ragged})
 }
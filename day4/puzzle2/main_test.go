@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"common/grid"
+	"common/wordsearch"
+)
+
+// toStrings converts a grid of bytes into the grid of characters searchForXmas works on.
+func toStrings(g *grid.Grid[byte]) *grid.Grid[string] {
+	text := grid.New[string](g.Rows(), g.Cols())
+	g.ForEach(func(p grid.Point, char byte) {
+		text.Set(p, string(char))
+	})
+	return text
+}
+
+func TestSearchForXmas(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     int
+	}{
+		{"input_test.txt", 9},
+		{"input.txt", 2048},
+	}
+
+	for _, test := range tests {
+		g, err := parseInputFile(test.filename, false)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %v", test.filename, err)
+		}
+
+		if got := searchForXmas(g); got != test.want {
+			t.Errorf("%s: got %d x-mas occurrences, want %d", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestGeneratedGrid(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		generated, err := wordsearch.Generate(wordsearch.GeneratorOptions{
+			Seed:    seed,
+			Rows:    100,
+			Cols:    100,
+			Words:   500,
+			Crosses: 800,
+		})
+		if err != nil {
+			t.Fatalf("seed %d: unable to generate grid: %v", seed, err)
+		}
+
+		if got := searchForXmas(toStrings(generated.Grid)); got != generated.Crosses {
+			t.Errorf("seed %d: got %d x-mas occurrences, want %d", seed, got, generated.Crosses)
+		}
+	}
+}
+
+func TestFindXmasParallelMatchesSequential(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		generated, err := wordsearch.Generate(wordsearch.GeneratorOptions{
+			Seed:    seed,
+			Rows:    150 + int(seed),
+			Cols:    120,
+			Crosses: 1500,
+		})
+		if err != nil {
+			t.Fatalf("seed %d: unable to generate grid: %v", seed, err)
+		}
+		g := toStrings(generated.Grid)
+
+		want := findXmas(g, 1)
+		for _, workers := range []int{2, 3, 8, 200} {
+			got := findXmas(g, workers)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("seed %d: %d workers found %d x-mas, which don't match the %d found sequentially",
+					seed, workers, len(got), len(want))
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"common/depgraph"
+)
+
+// runExport implements the export subcommand, which writes the rules as a diagram, either all of them or just the
+// ones that apply to a single print order.
+func runExport(args []string, source inputSource) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	input := flags.String("input", source.filename, "file to read the rules and print orders from")
+	format := flags.String("format", "dot", "diagram format, dot or mermaid")
+	orderNumber := flags.Int("order", 0, "only export the rules for this print order, counting from 1, with the rules it breaks highlighted")
+	out := flags.String("out", "", "file to write the diagram to (standard output by default)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// The diagram goes to standard output by default, so keep it clear of the parsing details
+	debugOutput = os.Stderr
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*input, source.format, parseIntID)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return exportRules(os.Stdout, *format, orderRules, printOrders, *orderNumber)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("unable to create %s due to: %w", *out, err)
+	}
+	defer file.Close()
+
+	return exportRules(file, *format, orderRules, printOrders, *orderNumber)
+}
+
+// exportRules writes the rules as a diagram in the named format. If orderNumber isn't 0 only the rules between the
+// pages of that print order are written, with the pages in print order and the rules the order breaks highlighted.
+func exportRules(w io.Writer, format string, rules map[int][]int, printOrders [][]int, orderNumber int) error {
+	graph := depgraph.FromRules(rules)
+	name := "rules"
+	highlighted := make(map[[2]int]bool)
+
+	if orderNumber != 0 {
+		if orderNumber < 1 || orderNumber > len(printOrders) {
+			return fmt.Errorf("there is no print order %d, there are %d of them", orderNumber, len(printOrders))
+		}
+
+		order := printOrders[orderNumber-1]
+		graph = graph.Subgraph(order)
+		name = fmt.Sprintf("order %d", orderNumber)
+		for _, v := range validatePrintOrder(order, rules).Violations {
+			highlighted[[2]int{v.Page, v.EarlierPage}] = true
+		}
+	}
+
+	switch format {
+	case "dot":
+		return graph.WriteDOT(w, name, highlighted)
+	case "mermaid":
+		return graph.WriteMermaid(w, highlighted)
+	}
+	return fmt.Errorf("unknown diagram format %q, expected dot or mermaid", format)
+}
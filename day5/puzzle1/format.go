@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"common/depgraph"
+)
+
+// inputFormat describes how the rules and print orders are written. The input is always a section of rules, one
+// per line, then a blank line, then a section of print orders, one per line.
+type inputFormat struct {
+	// RuleDelimiter separates the two pages of a rule, such as | in 47|53
+	RuleDelimiter string
+	// OrderDelimiter separates the pages of a print order, such as , in 75,47,61
+	OrderDelimiter string
+}
+
+// puzzleFormat is the format of the puzzle input.
+var puzzleFormat = inputFormat{RuleDelimiter: "|", OrderDelimiter: ","}
+
+func (f inputFormat) validate() error {
+	if f.RuleDelimiter == "" || f.OrderDelimiter == "" {
+		return fmt.Errorf("the rule and print order delimiters can't be empty")
+	}
+	if f.RuleDelimiter == f.OrderDelimiter {
+		return fmt.Errorf("the rule and print order delimiters can't both be %q", f.RuleDelimiter)
+	}
+	return nil
+}
+
+// inputSource is where the input is read from by default, and the format it is written in, as set by the flags
+// given before a subcommand.
+type inputSource struct {
+	filename string
+	format   inputFormat
+}
+
+// formatRule writes a rule the way it is written in the input.
+func (f inputFormat) formatRule(page int, later int) string {
+	return fmt.Sprintf("%d%s%d", page, f.RuleDelimiter, later)
+}
+
+// parseIntID parses a page that is a number, as the puzzle pages are.
+func parseIntID(raw string) (int, error) {
+	page, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse page %q as int due to: %w", raw, err)
+	}
+	return page, nil
+}
+
+// parseStringID keeps a page as the text it is written as.
+func parseStringID(raw string) (string, error) {
+	return raw, nil
+}
+
+// parseInputFileWithFormat opens the file and parses it with parseInput.
+func parseInputFileWithFormat[K depgraph.Ordered](filename string, format inputFormat,
+	parseID func(string) (K, error)) (map[K][]K, [][]K, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	rules, printOrders, err := parseInput(file, format, parseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse %s due to: %w", filename, err)
+	}
+	return rules, printOrders, nil
+}
+
+// parseInput parses rules and print orders written in the format, turning each page into an ID with parseID.
+// Spaces around pages are ignored, so a rule can be written as "a -> b". Errors say which line they are on, and
+// point out when the blank line between the sections looks to be missing, or when there is more than one.
+func parseInput[K depgraph.Ordered](r io.Reader, format inputFormat,
+	parseID func(string) (K, error)) (map[K][]K, [][]K, error) {
+	if err := format.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	orderingRules := make(map[K][]K, 0)
+	printOrders := make([][]K, 0)
+	allRulesParsed := false
+	// blankAfterOrders is the line of a blank line found after the print orders, which is only allowed at the end
+	blankAfterOrders := 0
+
+	parsePage := func(lineNumber int, raw string) (K, error) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			var empty K
+			return empty, fmt.Errorf("line %d: missing page", lineNumber)
+		}
+		page, err := parseID(raw)
+		if err != nil {
+			return page, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		return page, nil
+	}
+
+	// Using bufio to read the input file line by line
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+		fmt.Fprintf(debugOutput, "Parsed line: %s\n", line)
+
+		if strings.TrimSpace(line) == "" {
+			if allRulesParsed && len(printOrders) > 0 && blankAfterOrders == 0 {
+				blankAfterOrders = lineNumber
+			}
+			// This is the empty line separator between the ordering rules and the printing orders
+			allRulesParsed = true
+			continue
+		}
+
+		if blankAfterOrders != 0 {
+			return nil, nil, fmt.Errorf("line %d: unexpected section after the blank line on line %d, only the rules "+
+				"and the print orders are expected", lineNumber, blankAfterOrders)
+		}
+
+		// We are in first section - parse the ordering rules
+		if !allRulesParsed {
+			ordering := strings.Split(line, format.RuleDelimiter)
+			if len(ordering) != 2 {
+				if len(ordering) == 1 && strings.Contains(line, format.OrderDelimiter) {
+					return nil, nil, fmt.Errorf("line %d: expected a rule of two pages separated by %q, got what looks "+
+						"like a print order %q, is the blank line between the rules and the print orders missing?",
+						lineNumber, format.RuleDelimiter, line)
+				}
+				return nil, nil, fmt.Errorf("line %d: expected a rule of two pages separated by %q, got %q",
+					lineNumber, format.RuleDelimiter, line)
+			}
+
+			firstTerm, err := parsePage(lineNumber, ordering[0])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			secondTerm, err := parsePage(lineNumber, ordering[1])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			orderingRules[firstTerm] = append(orderingRules[firstTerm], secondTerm)
+			continue
+		}
+
+		// We have parsed all rules, now parse the page orders
+		if strings.Contains(line, format.RuleDelimiter) {
+			return nil, nil, fmt.Errorf("line %d: expected a print order of pages separated by %q, got what looks like "+
+				"a rule %q, rules have to come before the blank line", lineNumber, format.OrderDelimiter, line)
+		}
+
+		rawPageNumbers := strings.Split(line, format.OrderDelimiter)
+
+		pageNumbers := make([]K, len(rawPageNumbers))
+		for i, rawNum := range rawPageNumbers {
+			converted, err := parsePage(lineNumber, rawNum)
+			if err != nil {
+				return nil, nil, err
+			}
+			pageNumbers[i] = converted
+		}
+
+		printOrders = append(printOrders, pageNumbers)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading input file due to : %w", err)
+	}
+
+	if !allRulesParsed {
+		return nil, nil, fmt.Errorf("no blank line found between the rules and the print orders")
+	}
+
+	return orderingRules, printOrders, nil
+}
+
+// listValidMiddles prints the middle page of every valid print order in a file with string page identifiers.
+func listValidMiddles(filename string, format inputFormat) error {
+	orderRules, printOrders, err := parseInputFileWithFormat(filename, format, parseStringID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkRules(depgraph.FromRules(orderRules), printOrders); err != nil {
+		return err
+	}
+
+	middles := validMiddles(printOrders, orderRules)
+	fmt.Printf("The middle pages of the %d valid print orders are: %s\n", len(middles), strings.Join(middles, ", "))
+	return nil
+}
+
+// validMiddles returns the middle page of each print order that follows the rules.
+func validMiddles[K depgraph.Ordered](printOrders [][]K, rules map[K][]K) []K {
+	middles := make([]K, 0)
+	for _, order := range printOrders {
+		if result := validatePrintOrder(order, rules); result.Valid {
+			middles = append(middles, result.Middle)
+		}
+	}
+	return middles
+}
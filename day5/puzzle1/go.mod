@@ -1,3 +1,7 @@
 module ordering-rules
 
 go 1.20
+
+require common v0.0.0
+
+replace common => ../../common
@@ -0,0 +1,74 @@
+package main
+
+// maxIndexedPage is one more than the largest page number ruleIndex keeps in its bitsets. The puzzle only uses
+// two digit page numbers, so this comfortably covers them.
+const maxIndexedPage = 128
+
+// pageSet is a set of page numbers below maxIndexedPage, with one bit per page.
+type pageSet [maxIndexedPage / 64]uint64
+
+func (s *pageSet) add(page int) {
+	s[page/64] |= 1 << (page % 64)
+}
+
+func (s *pageSet) intersects(other *pageSet) bool {
+	for i := range s {
+		if s[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleIndex holds the rules as an adjacency matrix of bitsets, so checking a page against every rule for it is a
+// couple of AND instructions instead of a loop over the rules with a map lookup for each.
+type ruleIndex struct {
+	// mustPrecede holds, for each page, the set of pages that page must be printed before
+	mustPrecede [maxIndexedPage]pageSet
+	// rules are the rules the index was built from, which are checked the slow way if a rule has a page too big
+	// to fit in the bitsets
+	rules map[int][]int
+	// indexed is false if any rule has a page too big for the bitsets, in which case they aren't used at all
+	indexed bool
+}
+
+// newRuleIndex builds the index for the rules.
+func newRuleIndex(rules map[int][]int) *ruleIndex {
+	index := &ruleIndex{rules: rules, indexed: true}
+	for page, laterPages := range rules {
+		for _, later := range laterPages {
+			if !fitsIndex(page) || !fitsIndex(later) {
+				index.indexed = false
+				return index
+			}
+			index.mustPrecede[page].add(later)
+		}
+	}
+	return index
+}
+
+func fitsIndex(page int) bool {
+	return page >= 0 && page < maxIndexedPage
+}
+
+// valid reports whether the print order follows every rule, the same as validatePrintOrder but much faster.
+// Walking through the order, the pages seen so far are kept in a set, and a page is out of order if any of the
+// pages it must be printed before are in it.
+func (index *ruleIndex) valid(printOrder []int) bool {
+	if !index.indexed {
+		return validatePrintOrder(printOrder, index.rules).Valid
+	}
+
+	var seen pageSet
+	for _, page := range printOrder {
+		// Every rule fits in the index, so a page that doesn't isn't in any rule and can't break one
+		if !fitsIndex(page) {
+			continue
+		}
+		if index.mustPrecede[page].intersects(&seen) {
+			return false
+		}
+		seen.add(page)
+	}
+	return true
+}
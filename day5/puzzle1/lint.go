@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"common/depgraph"
+)
+
+// duplicateRule is a rule written more than once.
+type duplicateRule struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// redundantRule is a rule that follows from other rules, so leaving it out changes nothing.
+type redundantRule struct {
+	Rule string `json:"rule"`
+	// Via is a page the first page of the rule must come before, which in turn must come before the second page
+	Via int `json:"via"`
+}
+
+// ruleCycle is a group of pages whose rules go round in a circle, so there is no order that follows all of them.
+type ruleCycle struct {
+	// Pages are all of the pages that can be reached from each other through the rules
+	Pages []int `json:"pages"`
+	// Example is one cycle through some of the pages, where each page must come before the next and the last must
+	// come before the first
+	Example []int `json:"example"`
+}
+
+// lintReport lists the problems found in a set of rules.
+type lintReport struct {
+	Duplicates []duplicateRule `json:"duplicates"`
+	// Redundant rules follow from the other rules, and can all be left out together without changing which pages
+	// must come before which
+	Redundant []redundantRule `json:"redundant"`
+	// Contradictions are pairs of rules that each put a page before the other, written as the two rules
+	Contradictions [][2]string `json:"contradictions"`
+	// Cycles are groups of three or more pages whose rules go round in a circle, and single pages with a rule
+	// putting them before themselves
+	Cycles            []ruleCycle `json:"cycles"`
+	PagesWithoutRules []int       `json:"pagesWithoutRules"`
+}
+
+// runLint implements the lint subcommand, which reports duplicated, redundant and contradictory rules.
+func runLint(args []string, source inputSource) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	input := flags.String("input", source.filename, "file to read the rules and print orders from")
+	format := flags.String("format", "text", "write the problems found as text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		debugOutput = os.Stderr
+	}
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*input, source.format, parseIntID)
+	if err != nil {
+		return err
+	}
+	return writeLintReport(os.Stdout, *format, lintRules(orderRules, printOrders, source.format))
+}
+
+// lintRules checks the rules for problems, writing the rules in the problems found in the format. The print orders
+// are only used to find pages without any rules.
+func lintRules(rules map[int][]int, printOrders [][]int, format inputFormat) lintReport {
+	r := lintReport{
+		Duplicates:        make([]duplicateRule, 0),
+		Redundant:         make([]redundantRule, 0),
+		Contradictions:    make([][2]string, 0),
+		Cycles:            make([]ruleCycle, 0),
+		PagesWithoutRules: make([]int, 0),
+	}
+	graph := depgraph.FromRules(rules)
+
+	// The rules map keeps every rule that was written, so a duplicate is a later page listed twice for a page.
+	// Going through the edges keeps the output in a fixed order.
+	for _, edge := range graph.Edges() {
+		count := 0
+		for _, later := range rules[edge[0]] {
+			if later == edge[1] {
+				count++
+			}
+		}
+		if count > 1 {
+			r.Duplicates = append(r.Duplicates, duplicateRule{Rule: format.formatRule(edge[0], edge[1]), Count: count})
+		}
+	}
+
+	for _, pages := range graph.StronglyConnectedComponents() {
+		if len(pages) > 2 {
+			r.Cycles = append(r.Cycles, ruleCycle{Pages: pages, Example: graph.Subgraph(pages).FindCycle()})
+		}
+	}
+
+	// A rule is redundant if there is another way from its first page to its second through the other rules. Two
+	// rules can each be the other's way round, like 1|3 and 1|4 with 3|4 and 4|3, so the redundant rules are left
+	// out of reduced as they are found, and only the rules that are left count as a way round.
+	reduced := graph.Subgraph(graph.Nodes())
+	for _, edge := range graph.Edges() {
+		from, to := edge[0], edge[1]
+		if from == to {
+			r.Cycles = append(r.Cycles, ruleCycle{Pages: []int{from}, Example: []int{from}})
+			continue
+		}
+
+		// Each contradiction is found from both sides, so only report it from the smaller page
+		if from < to && graph.HasEdge(to, from) {
+			r.Contradictions = append(r.Contradictions, [2]string{format.formatRule(from, to),
+				format.formatRule(to, from)})
+		}
+
+		if via, ok := reduced.Bypass(from, to); ok {
+			r.Redundant = append(r.Redundant, redundantRule{Rule: format.formatRule(from, to), Via: via})
+			reduced.RemoveEdge(from, to)
+		}
+	}
+
+	seen := make(map[int]bool)
+	for _, order := range printOrders {
+		for _, page := range order {
+			if !graph.HasNode(page) && !seen[page] {
+				seen[page] = true
+				r.PagesWithoutRules = append(r.PagesWithoutRules, page)
+			}
+		}
+	}
+	sort.Ints(r.PagesWithoutRules)
+
+	return r
+}
+
+// writeLintReport writes the problems found in the named format, which is text or json.
+func writeLintReport(w io.Writer, format string, r lintReport) error {
+	switch format {
+	case "text":
+		return writeTextLintReport(w, r)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	return fmt.Errorf("unknown lint format %q, expected text or json", format)
+}
+
+// writeTextLintReport writes a section for each kind of problem, with a line for each problem found.
+func writeTextLintReport(w io.Writer, r lintReport) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "%d duplicated rules\n", len(r.Duplicates))
+	for _, d := range r.Duplicates {
+		fmt.Fprintf(out, "  %s is written %d times\n", d.Rule, d.Count)
+	}
+
+	fmt.Fprintf(out, "%d redundant rules\n", len(r.Redundant))
+	for _, redundant := range r.Redundant {
+		fmt.Fprintf(out, "  %s follows from the rules through %d\n", redundant.Rule, redundant.Via)
+	}
+
+	fmt.Fprintf(out, "%d contradictions\n", len(r.Contradictions))
+	for _, c := range r.Contradictions {
+		fmt.Fprintf(out, "  %s contradicts %s\n", c[0], c[1])
+	}
+
+	fmt.Fprintf(out, "%d cycles\n", len(r.Cycles))
+	for _, c := range r.Cycles {
+		if len(c.Pages) == 1 {
+			fmt.Fprintf(out, "  %d must come before itself\n", c.Pages[0])
+			continue
+		}
+		fmt.Fprintf(out, "  %d pages are on cycles together (%s), such as %s -> %d\n", len(c.Pages), joinPages(c.Pages),
+			joinPagesWith(c.Example, " -> "), c.Example[0])
+	}
+
+	fmt.Fprintf(out, "%d pages without rules\n", len(r.PagesWithoutRules))
+	if len(r.PagesWithoutRules) > 0 {
+		fmt.Fprintf(out, "  %s\n", joinPages(r.PagesWithoutRules))
+	}
+	return out.Flush()
+}
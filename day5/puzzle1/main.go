@@ -1,13 +1,18 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"strings"
+
+	"common/depgraph"
 )
 
+// debugOutput receives the details printed while parsing the input. It is standard error instead of standard
+// output when the output has to stay machine readable.
+var debugOutput io.Writer = os.Stdout
+
 func main() {
 	// Given a set of ordering rules and print orders, calculate the sum of the middle values of all
 	// valid orders.
@@ -22,47 +27,189 @@ func main() {
 	// We only really care about what we have seen in the past, so we just need to keep track
 	// of everything we have seen in a map (for quick lookup) and walk through the values, checking
 	// memory and storing as we go
-	orderRules, printOrders, err := parseInputFile("input.txt")
+	reportFormat := flag.String("report", "", "explain why each print order is valid or not, as text or json")
+	inputFile := flag.String("input", "input.txt", "file to read the rules and print orders from")
+	ruleDelimiter := flag.String("rule-delimiter", puzzleFormat.RuleDelimiter, "separator between the two pages of a rule, such as ->")
+	orderDelimiter := flag.String("order-delimiter", puzzleFormat.OrderDelimiter, "separator between the pages of a print order")
+	ids := flag.String("ids", "int", "type of the page identifiers, int or string")
+	flag.Parse()
+
+	format := inputFormat{RuleDelimiter: *ruleDelimiter, OrderDelimiter: *orderDelimiter}
+	switch *ids {
+	case "int":
+	case "string":
+		// The answer adds up page numbers, so with any other kind of page the middle pages are listed instead
+		if flag.NArg() > 0 || *reportFormat != "" {
+			panic(fmt.Errorf("-ids string only lists the valid middle pages, it can't be combined with -report or a subcommand"))
+		}
+		if err := listValidMiddles(*inputFile, format); err != nil {
+			panic(err)
+		}
+		return
+	default:
+		panic(fmt.Errorf("unknown page identifier type %q, expected int or string", *ids))
+	}
+
+	// The subcommands read the input from the file and in the format given before them, unless they are given
+	// their own -input
+	source := inputSource{filename: *inputFile, format: format}
+	if flag.NArg() > 0 && *reportFormat != "" {
+		panic(fmt.Errorf("-report only applies without a subcommand, got %s", flag.Arg(0)))
+	}
+
+	switch flag.Arg(0) {
+	case "repair":
+		if err := runRepair(flag.Args()[1:], source); err != nil {
+			panic(err)
+		}
+		return
+	case "lint":
+		if err := runLint(flag.Args()[1:], source); err != nil {
+			panic(err)
+		}
+		return
+	case "orderings":
+		if err := runOrderings(flag.Args()[1:], source); err != nil {
+			panic(err)
+		}
+		return
+	case "export":
+		if err := runExport(flag.Args()[1:], source); err != nil {
+			panic(err)
+		}
+		return
+	case "queue":
+		if err := runQueue(flag.Args()[1:], source); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *reportFormat == "json" {
+		debugOutput = os.Stderr
+	}
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*inputFile, format, parseIntID)
 
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("The parsed ordering rules are: %v\n", orderRules)
-	fmt.Printf("The parsed printing orders are: %v\n", printOrders)
+	fmt.Fprintf(debugOutput, "The parsed ordering rules are: %v\n", orderRules)
+	fmt.Fprintf(debugOutput, "The parsed printing orders are: %v\n", printOrders)
+
+	if err := checkRules(depgraph.FromRules(orderRules), printOrders); err != nil {
+		panic(err)
+	}
+
+	if *reportFormat != "" {
+		if err := writeReport(os.Stdout, *reportFormat, buildReport(printOrders, orderRules, format)); err != nil {
+			panic(err)
+		}
+		return
+	}
 
 	sumOfValidMiddles := 0
 
+	// The index answers whether an order is valid much faster than validatePrintOrder, which is only needed
+	// to explain why an order isn't
+	index := newRuleIndex(orderRules)
 	for _, order := range printOrders {
-		sumOfValidMiddles += validatePrintOrder(order, orderRules)
+		if index.valid(order) {
+			sumOfValidMiddles += order[(len(order)-1)/2]
+		}
 	}
 
 	fmt.Printf("The sum of the valid print orders is %d", sumOfValidMiddles)
 }
 
-// validatePrintOrder validates the provided print order against the supplied rules.
-// If the order is valid, it returns the middle page number in the order (otherwise, returns 0).
-func validatePrintOrder(printOrder []int, rules map[int][]int) int {
-	// Since go doesn't have a set type, using a map to emulate one (i'm too lazy to create a whole type definition right now)
-	previous := make(map[int]bool, len(printOrder))
-
-	for _, pageNum := range printOrder {
-		rulesForPage, ok := rules[pageNum]
-		if ok {
-			// For each number in the "rules", make sure that we did not already see it in the past
-			for _, rule := range rulesForPage {
-				if _, ok := previous[rule]; ok {
-					// We saw this in the past which means the rule is violated! Fail
-					return 0
-				}
+// checkRules makes sure the rules that apply to each print order don't contradict each other. The whole set of
+// rules is allowed to contain cycles (the puzzle input does), since a rule only applies when both of its pages are
+// in the order, but there must be a way to put the pages of every single order in a valid order.
+func checkRules[K depgraph.Ordered](rules *depgraph.Graph[K], printOrders [][]K) error {
+	for i, order := range printOrders {
+		if _, err := rules.Subgraph(order).TopologicalSort(); err != nil {
+			return fmt.Errorf("rules for print order %d %v are inconsistent due to: %w", i+1, order, err)
+		}
+	}
+
+	return nil
+}
+
+// sortPrintOrder puts the pages of the print order in an order that follows the rules, as part 2 asks. Pages keep
+// their place relative to each other wherever the rules allow, so a valid order is left as it is. If the rules for
+// the pages contradict each other, a *depgraph.CycleError is returned. A page can't be in the order twice, since
+// there is no telling which of its places the rules are about.
+func sortPrintOrder(printOrder []int, rules *depgraph.Graph[int]) ([]int, error) {
+	seen := make(map[int]bool, len(printOrder))
+	for _, page := range printOrder {
+		if seen[page] {
+			return nil, fmt.Errorf("unable to sort print order %v as page %d is in it twice", printOrder, page)
+		}
+		seen[page] = true
+	}
+
+	sorted, err := rules.Subgraph(printOrder).TopologicalSort()
+	if err != nil {
+		return nil, fmt.Errorf("unable to sort print order %v due to: %w", printOrder, err)
+	}
+	return sorted, nil
+}
+
+// violation is a rule broken by a print order, where a page is printed after a page it should have come before.
+type violation[K depgraph.Ordered] struct {
+	// Page is the page that should have been printed first
+	Page K `json:"page"`
+	// Position is where the page is in the order, counting from 1
+	Position int `json:"position"`
+	// EarlierPage is the page printed before it that should have come after it
+	EarlierPage K `json:"earlierPage"`
+	// EarlierPosition is where the earlier page is in the order, counting from 1
+	EarlierPosition int `json:"earlierPosition"`
+	// Rule is the rule that was broken, as written in the input. validatePrintOrder doesn't know how the input was
+	// written and leaves it empty, and buildReport fills it in.
+	Rule string `json:"rule"`
+}
+
+// validationResult is the outcome of checking a print order against the rules.
+type validationResult[K depgraph.Ordered] struct {
+	Valid bool `json:"valid"`
+	// Middle is the middle page of the order, whether or not it is valid
+	Middle K `json:"middle"`
+	// Violations lists every rule the order breaks, in the order they are found walking through the pages
+	Violations []violation[K] `json:"violations"`
+}
+
+// validatePrintOrder validates the provided print order against the supplied rules, and reports every rule
+// it breaks along with the middle page number of the order.
+func validatePrintOrder[K depgraph.Ordered](printOrder []K, rules map[K][]K) validationResult[K] {
+	result := validationResult[K]{Valid: true, Violations: make([]violation[K], 0)}
+	if len(printOrder) > 0 {
+		result.Middle = printOrder[(len(printOrder)-1)/2]
+	}
+
+	// Remember where each page was seen, so both positions of a broken rule can be reported
+	previous := make(map[K]int, len(printOrder))
+
+	for i, pageNum := range printOrder {
+		// For each number in the "rules", make sure that we did not already see it in the past
+		for _, rule := range rules[pageNum] {
+			if position, ok := previous[rule]; ok {
+				// We saw this in the past which means the rule is violated!
+				result.Valid = false
+				result.Violations = append(result.Violations, violation[K]{
+					Page:            pageNum,
+					Position:        i + 1,
+					EarlierPage:     rule,
+					EarlierPosition: position,
+				})
 			}
 		}
 
-		previous[pageNum] = true
+		previous[pageNum] = i + 1
 	}
 
-	// If we've made it here, it means the order passed all the rules.
-	return printOrder[(len(printOrder)-1)/2]
+	return result
 }
 
 // parseInputFile parses the input file line by line, and parsing the ordering rules and printing
@@ -97,70 +244,7 @@ func validatePrintOrder(printOrder []int, rules map[int][]int) int {
 // 97,13,75,29,47
 //
 // The function returns the page ordering rules as a map, and the printing orders as a slice of
-// int slices
+// int slices. Input in other formats is read with parseInputFileWithFormat.
 func parseInputFile(filename string) (map[int][]int, [][]int, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer file.Close()
-
-	orderingRules := make(map[int][]int, 0)
-	printOrders := make([][]int, 0)
-	allRulesParsed := false
-
-	// Using bufio to read the input file line by line
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fmt.Printf("Parsed line: %s\n", line)
-
-		// This is the empty line separator between the ordering rules and the printing orders
-		if strings.TrimSpace(line) == "" {
-			allRulesParsed = true
-			continue
-		}
-
-		// We are in first section - parse pipe-delimited ordering rules
-		if !allRulesParsed {
-			ordering := strings.Split(line, "|")
-
-			firstTerm, err := strconv.Atoi(ordering[0])
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to parse first term %s as int due to: %w", ordering[0], err)
-			}
-
-			secondTerm, err := strconv.Atoi(ordering[1])
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to parse second term %s as int due to: %w", ordering[1], err)
-			}
-
-			if existing, ok := orderingRules[firstTerm]; ok {
-				orderingRules[firstTerm] = append(existing, secondTerm)
-			} else {
-				orderingRules[firstTerm] = []int{secondTerm}
-			}
-			continue
-		}
-
-		// We have parsed all rules, now parse comma-delimited page number orders
-		rawPageNumbers := strings.Split(line, ",")
-
-		pageNumbers := make([]int, len(rawPageNumbers))
-		for i, rawNum := range rawPageNumbers {
-			converted, err := strconv.Atoi(rawNum)
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to parse page number %s as int due to: %w", rawNum, err)
-			}
-			pageNumbers[i] = converted
-		}
-
-		printOrders = append(printOrders, pageNumbers)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, nil, fmt.Errorf("error reading input file due to : %w", err)
-	}
-
-	return orderingRules, printOrders, nil
+	return parseInputFileWithFormat(filename, puzzleFormat, parseIntID)
 }
@@ -0,0 +1,956 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"common/depgraph"
+)
+
+func init() {
+	// Keep the details printed while parsing out of the test output
+	debugOutput = io.Discard
+}
+
+// randomInput builds rules and print orders in the style of the puzzle input. The rules come from a random order of
+// the pages 10 to 99, so they never contradict each other, and each pair of pages has a rule with the provided
+// probability. The print orders are random selections of an odd number of pages, in a random order.
+func randomInput(rng *rand.Rand, orders int, ruleProbability float64) (map[int][]int, [][]int) {
+	pages := rng.Perm(90)
+	for i := range pages {
+		pages[i] += 10
+	}
+
+	rules := make(map[int][]int)
+	for i, page := range pages {
+		for _, later := range pages[i+1:] {
+			if rng.Float64() < ruleProbability {
+				rules[page] = append(rules[page], later)
+			}
+		}
+	}
+
+	printOrders := make([][]int, orders)
+	for i := range printOrders {
+		order := rng.Perm(90)[:5+2*rng.Intn(10)]
+		for j := range order {
+			order[j] += 10
+		}
+		printOrders[i] = order
+	}
+	return rules, printOrders
+}
+
+func TestValidatePrintOrder(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     int
+	}{
+		{"input_test.txt", 143},
+		{"input.txt", 4609},
+	}
+
+	for _, test := range tests {
+		rules, printOrders, err := parseInputFile(test.filename)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %v", test.filename, err)
+		}
+
+		index := newRuleIndex(rules)
+		sum, indexSum := 0, 0
+		for _, order := range printOrders {
+			if result := validatePrintOrder(order, rules); result.Valid {
+				sum += result.Middle
+			}
+			if index.valid(order) {
+				indexSum += order[(len(order)-1)/2]
+			}
+		}
+
+		if sum != test.want || indexSum != test.want {
+			t.Errorf("%s: got %d from validatePrintOrder and %d from the index, want %d", test.filename, sum, indexSum,
+				test.want)
+		}
+	}
+}
+
+// arrowFormat is an input format other than the puzzle's, to check rules are written back the way they were read.
+var arrowFormat = inputFormat{RuleDelimiter: "->", OrderDelimiter: ";"}
+
+func TestReport(t *testing.T) {
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := buildReport(printOrders, rules, puzzleFormat)
+	if r.ValidOrders != 3 || r.InvalidOrders != 3 || r.SumOfValidMiddles != 143 {
+		t.Errorf("expected 3 valid and 3 invalid orders adding up to 143, got %d, %d and %d", r.ValidOrders,
+			r.InvalidOrders, r.SumOfValidMiddles)
+	}
+
+	// Order 4 is 75,97,47,61,53, where 97 should have been printed before 75
+	order := r.Orders[3]
+	want := []violation[int]{{Page: 97, Position: 2, EarlierPage: 75, EarlierPosition: 1, Rule: "97|75"}}
+	if order.Number != 4 || order.Valid || !reflect.DeepEqual(order.Violations, want) {
+		t.Errorf("expected order 4 to break only 97|75, got %+v", order)
+	}
+
+	var encoded strings.Builder
+	if err := writeReport(&encoded, "json", r); err != nil {
+		t.Fatal(err)
+	}
+	var decoded report
+	if err := json.Unmarshal([]byte(encoded.String()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, r) {
+		t.Errorf("expected the JSON report to decode to the same report, got %+v", decoded)
+	}
+	for _, field := range []string{`"earlierPosition": 1`, `"rule": "97|75"`, `"sumOfValidMiddles": 143`} {
+		if !strings.Contains(encoded.String(), field) {
+			t.Errorf("expected the JSON report to contain %s", field)
+		}
+	}
+
+	var text strings.Builder
+	if err := writeReport(&text, "text", r); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(text.String(), "\n"), "\n")
+	wantLines := map[int]string{
+		0:  "Order 1 (75,47,61,53,29) is valid, with middle page 61",
+		3:  "Order 4 (75,97,47,61,53) is invalid, breaking 1 rule:",
+		4:  "  97 at position 2 is printed after 75 at position 1, breaking rule 97|75",
+		12: "3 of 6 orders are valid, and the sum of their middle pages is 143",
+	}
+	if len(lines) != 13 {
+		t.Fatalf("expected 13 lines, got %q", lines)
+	}
+	for i, line := range wantLines {
+		if lines[i] != line {
+			t.Errorf("line %d: got %q, want %q", i+1, lines[i], line)
+		}
+	}
+
+	// The broken rules are written with the delimiter the input uses
+	text.Reset()
+	if err := writeReport(&text, "text", buildReport(printOrders, rules, arrowFormat)); err != nil {
+		t.Fatal(err)
+	}
+	wantLine := "  97 at position 2 is printed after 75 at position 1, breaking rule 97->75\n"
+	if !strings.Contains(text.String(), wantLine) {
+		t.Errorf("expected the report to contain %q, got\n%s", wantLine, text.String())
+	}
+
+	if err := writeReport(io.Discard, "xml", r); err == nil {
+		t.Errorf("expected an unknown report format to be rejected")
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	arrows := inputFormat{RuleDelimiter: "->", OrderDelimiter: ","}
+	input := "shoes -> socks\nsocks -> trousers\n\nsocks, trousers, shoes\nshoes,socks,hat\n\n"
+
+	rules, printOrders, err := parseInput(strings.NewReader(input), arrows, parseStringID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"shoes": {"socks"}, "socks": {"trousers"}}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("got rules %v, want %v", rules, want)
+	}
+	if got := validMiddles(printOrders, rules); !reflect.DeepEqual(got, []string{"socks"}) {
+		t.Errorf("expected only the second order to be valid, with middle page socks, got %v", got)
+	}
+
+	errorTests := []struct {
+		name   string
+		input  string
+		format inputFormat
+		want   string
+	}{
+		{"missing blank line", "1|2\n1,2\n", puzzleFormat, "is the blank line between the rules and the print orders missing?"},
+		{"no print orders", "1|2\n2|3\n", puzzleFormat, "no blank line found between the rules and the print orders"},
+		{"short rule", "1|2\n3\n\n1,2\n", puzzleFormat, `line 2: expected a rule of two pages separated by "|", got "3"`},
+		{"long rule", "1|2|3\n\n1,2\n", puzzleFormat, `line 1: expected a rule of two pages separated by "|", got "1|2|3"`},
+		{"missing page", "1|\n\n1,2\n", puzzleFormat, "line 1: missing page"},
+		{"rule after orders", "1|2\n\n1,2\n2|3\n", puzzleFormat, "got what looks like a rule \"2|3\""},
+		{"extra section", "1|2\n\n1,2\n\n3,4\n", puzzleFormat, "line 5: unexpected section after the blank line on line 4"},
+		{"not a number", "1|two\n\n1,2\n", puzzleFormat, `line 1: unable to parse page "two" as int`},
+		{"same delimiters", "1,2\n\n1,2\n", inputFormat{RuleDelimiter: ",", OrderDelimiter: ","}, "can't both be"},
+	}
+	for _, test := range errorTests {
+		_, _, err := parseInput(strings.NewReader(test.input), test.format, parseIntID)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.name, test.want, err)
+		}
+	}
+}
+
+// quickRules is a random set of rules and a print order, generated for testing/quick. The rules come from a random
+// order of the pages, so they never contradict each other.
+type quickRules struct {
+	rules map[int][]int
+	order []int
+	// complete is set when there is a rule for every pair of pages, so there is only one valid order
+	complete bool
+}
+
+// quickCyclicRules is like quickRules, but with a cycle added between some of the pages of the print order.
+type quickCyclicRules struct {
+	quickRules
+}
+
+// quickRepeatedRules is like quickRules, but with a page of the print order in it twice.
+type quickRepeatedRules struct {
+	quickRules
+	repeated int
+}
+
+func (quickRules) Generate(rng *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(generateQuickRules(rng, size))
+}
+
+func generateQuickRules(rng *rand.Rand, size int) quickRules {
+	pages := rng.Perm(size + 2)
+	complete := rng.Intn(4) == 0
+	probability := rng.Float64()
+	if complete {
+		probability = 1
+	}
+
+	rules := make(map[int][]int)
+	for i, page := range pages {
+		for _, later := range pages[i+1:] {
+			if rng.Float64() < probability {
+				rules[page] = append(rules[page], later)
+			}
+		}
+	}
+
+	order := rng.Perm(len(pages))[:1+rng.Intn(len(pages))]
+	return quickRules{rules: rules, order: order, complete: complete}
+}
+
+func (quickCyclicRules) Generate(rng *rand.Rand, size int) reflect.Value {
+	input := generateQuickRules(rng, size)
+	for len(input.order) < 2 {
+		input = generateQuickRules(rng, size)
+	}
+
+	// Chaining some of the pages of the order and closing the chain makes a cycle, whatever the other rules are
+	cycle := rng.Perm(len(input.order))[:2+rng.Intn(len(input.order)-1)]
+	for i := range cycle {
+		cycle[i] = input.order[cycle[i]]
+	}
+	input.rules = withRule(input.rules, cycle[len(cycle)-1], cycle[0])
+	for i := 1; i < len(cycle); i++ {
+		input.rules[cycle[i-1]] = append(input.rules[cycle[i-1]], cycle[i])
+	}
+	return reflect.ValueOf(quickCyclicRules{quickRules: input})
+}
+
+func (quickRepeatedRules) Generate(rng *rand.Rand, size int) reflect.Value {
+	input := generateQuickRules(rng, size)
+	repeated := input.order[rng.Intn(len(input.order))]
+	at := rng.Intn(len(input.order) + 1)
+	input.order = append(input.order[:at:at], append([]int{repeated}, input.order[at:]...)...)
+	return reflect.ValueOf(quickRepeatedRules{quickRules: input, repeated: repeated})
+}
+
+func TestSortPrintOrderProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+
+	sortedIsValid := func(input quickRules) bool {
+		sorted, err := sortPrintOrder(input.order, depgraph.FromRules(input.rules))
+		return err == nil && validatePrintOrder(sorted, input.rules).Valid && newRuleIndex(input.rules).valid(sorted)
+	}
+	if err := quick.Check(sortedIsValid, config); err != nil {
+		t.Errorf("sorted orders should follow the rules: %v", err)
+	}
+
+	sortedIsPermutation := func(input quickRules) bool {
+		sorted, err := sortPrintOrder(input.order, depgraph.FromRules(input.rules))
+		if err != nil || len(sorted) != len(input.order) {
+			return false
+		}
+		counts := make(map[int]int)
+		for _, page := range input.order {
+			counts[page]++
+		}
+		for _, page := range sorted {
+			counts[page]--
+		}
+		for _, count := range counts {
+			if count != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(sortedIsPermutation, config); err != nil {
+		t.Errorf("sorting should only reorder the pages: %v", err)
+	}
+
+	// Sorting an order that is already valid leaves it alone, so its middle page can't change, and when the rules
+	// only allow one order every shuffle of the pages sorts to the same middle page
+	middleIsStable := func(input quickRules, seed int64) bool {
+		graph := depgraph.FromRules(input.rules)
+		sorted, err := sortPrintOrder(input.order, graph)
+		if err != nil {
+			return false
+		}
+		again, err := sortPrintOrder(sorted, graph)
+		if err != nil || !reflect.DeepEqual(again, sorted) ||
+			validatePrintOrder(again, input.rules).Middle != validatePrintOrder(sorted, input.rules).Middle {
+			return false
+		}
+		if !input.complete {
+			return true
+		}
+
+		shuffled := append([]int(nil), input.order...)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		resorted, err := sortPrintOrder(shuffled, graph)
+		return err == nil && reflect.DeepEqual(resorted, sorted)
+	}
+	if err := quick.Check(middleIsStable, config); err != nil {
+		t.Errorf("sorting should keep the middle page stable: %v", err)
+	}
+
+	cyclicIsRejected := func(input quickCyclicRules) bool {
+		graph := depgraph.FromRules(input.rules)
+		_, err := sortPrintOrder(input.order, graph)
+		var cycleErr *depgraph.CycleError[int]
+		return errors.As(err, &cycleErr) && len(cycleErr.Cycle) > 1 && checkRules(graph, [][]int{input.order}) != nil
+	}
+	if err := quick.Check(cyclicIsRejected, config); err != nil {
+		t.Errorf("rules with a cycle between the pages should be rejected: %v", err)
+	}
+
+	repeatedIsRejected := func(input quickRepeatedRules) bool {
+		_, err := sortPrintOrder(input.order, depgraph.FromRules(input.rules))
+		return err != nil && strings.Contains(err.Error(), fmt.Sprintf("page %d is in it twice", input.repeated))
+	}
+	if err := quick.Check(repeatedIsRejected, config); err != nil {
+		t.Errorf("orders with a page in them twice should be rejected: %v", err)
+	}
+}
+
+func TestRuleIndexMatchesValidatePrintOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 20; trial++ {
+		rules, printOrders := randomInput(rng, 200, rng.Float64())
+
+		// Sort some of the orders by the rules, so there are plenty of valid ones too
+		graph := depgraph.FromRules(rules)
+		for _, order := range printOrders[:100] {
+			sortByRules(t, order, graph)
+		}
+
+		// Pages outside of the bitsets, in the orders only and then in the rules too
+		printOrders = append(printOrders, []int{12, 500, 34})
+		for _, indexRules := range []map[int][]int{rules, withRule(rules, 1000, 12)} {
+			index := newRuleIndex(indexRules)
+			for _, order := range printOrders {
+				if got, want := index.valid(order), validatePrintOrder(order, indexRules).Valid; got != want {
+					t.Fatalf("order %v: index says valid is %t, validatePrintOrder says %t", order, got, want)
+				}
+				if got, want := mapValid(order, indexRules), index.valid(order); got != want {
+					t.Fatalf("order %v: benchmark baseline says valid is %t, index says %t", order, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRepairPrintOrder(t *testing.T) {
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repairs, err := repairPrintOrders(printOrders, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, len(repairs))
+	for i, r := range repairs {
+		got[i] = fmt.Sprintf("%d: %v %v %d", r.Number, r.Moves, r.MovedOrder, r.Swaps)
+	}
+	want := []string{
+		"4: [move 75 after 97] [97 75 47 61 53] 1",
+		"5: [move 13 after 29] [61 29 13] 1",
+		"6: [move 29 after 47 move 13 after 29] [97 75 47 29 13] 4",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteRepairsJSON(t *testing.T) {
+	// Page 0 has to be written as the page to move after, and the move to the front has no page to move after
+	repairs, err := repairPrintOrders([][]int{{5, 0, 3}, {3, 4, 7}}, map[int][]int{0: {5}, 7: {3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := writeRepairs(&out, "json", repairs); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []struct {
+		Moves []map[string]interface{} `json:"moves"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]map[string]interface{}{
+		{{"page": 5.0, "after": 0.0}},
+		{{"page": 7.0, "toFront": true}},
+	}
+	if len(decoded) != len(want) {
+		t.Fatalf("expected %d repairs, got %s", len(want), out.String())
+	}
+	for i, r := range decoded {
+		if !reflect.DeepEqual(r.Moves, want[i]) {
+			t.Errorf("repair %d: got moves %v, want %v", i+1, r.Moves, want[i])
+		}
+	}
+}
+
+func TestRepairPrintOrderMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 300; trial++ {
+		rules, _ := randomInput(rng, 0, rng.Float64())
+		graph := depgraph.FromRules(rules)
+		order := rng.Perm(90)[:1+rng.Intn(7)]
+		for i := range order {
+			order[i] += 10
+		}
+
+		r, err := repairPrintOrder(order, graph)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Making the moves one after the other has to give the valid order they were planned for
+		moved := append([]int(nil), order...)
+		for _, move := range r.Moves {
+			moved = applyMove(moved, move)
+		}
+		if !reflect.DeepEqual(moved, r.MovedOrder) || !validatePrintOrder(moved, rules).Valid {
+			t.Fatalf("order %v: moves %v give %v, expected the valid order %v", order, r.Moves, moved, r.MovedOrder)
+		}
+		if !validatePrintOrder(r.SwappedOrder, rules).Valid || inversions(order, r.SwappedOrder) != r.Swaps {
+			t.Fatalf("order %v: %v isn't a valid order %d swaps away", order, r.SwappedOrder, r.Swaps)
+		}
+
+		moves, swaps := bruteForceRepair(order, rules)
+		if len(r.Moves) != moves || r.Swaps != swaps {
+			t.Fatalf("order %v: got %d moves and %d swaps, want %d and %d", order, len(r.Moves), r.Swaps, moves, swaps)
+		}
+	}
+}
+
+func contains(pages []int, page int) bool {
+	for _, candidate := range pages {
+		if candidate == page {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepairPrintOrderWithNegativePages(t *testing.T) {
+	// Page -1 used to be mistaken for there being no page before it while chaining the pages that stay in place
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 100; trial++ {
+		order := rng.Perm(6)
+		for i := range order {
+			order[i] -= 3
+		}
+		rules := make(map[int][]int)
+		for _, page := range order {
+			for _, later := range order {
+				if page != later && rng.Intn(4) == 0 && !contains(rules[later], page) {
+					rules[page] = append(rules[page], later)
+				}
+			}
+		}
+
+		r, err := repairPrintOrder(order, depgraph.FromRules(rules))
+		if err != nil {
+			// The random rules can contradict each other
+			continue
+		}
+
+		moved := append([]int(nil), order...)
+		for _, move := range r.Moves {
+			moved = applyMove(moved, move)
+		}
+		moves, _ := bruteForceRepair(order, rules)
+		if !reflect.DeepEqual(moved, r.MovedOrder) || !validatePrintOrder(moved, rules).Valid || len(r.Moves) != moves {
+			t.Fatalf("order %v with rules %v: moves %v give %v, expected a valid order %d moves away", order, rules,
+				r.Moves, moved, moves)
+		}
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	rules := map[int][]int{
+		// 10|12 follows from 10|11 and 11|12, and 11|12 is written twice
+		10: {11, 12},
+		11: {12, 12},
+		// 20 and 21 contradict each other
+		20: {21},
+		21: {20},
+		// 30, 31 and 32 go round in a circle
+		30: {31},
+		31: {32},
+		32: {30},
+		// 50|52 can only be reached from 51 by going back through 50 and 50|52 itself, so it isn't redundant
+		50: {51, 52},
+		51: {50},
+		// 60 must come before itself
+		60: {60},
+		// 70|73 and 70|74 can each be reached through the other, but only one of them can be left out
+		70: {73, 74},
+		73: {74},
+		74: {73},
+	}
+	got := lintRules(rules, [][]int{{10, 11, 40}, {41, 40, 12}}, puzzleFormat)
+
+	want := lintReport{
+		Duplicates:     []duplicateRule{{Rule: "11|12", Count: 2}},
+		Redundant:      []redundantRule{{Rule: "10|12", Via: 11}, {Rule: "70|73", Via: 74}},
+		Contradictions: [][2]string{{"20|21", "21|20"}, {"50|51", "51|50"}, {"73|74", "74|73"}},
+		Cycles: []ruleCycle{
+			{Pages: []int{30, 31, 32}, Example: []int{30, 31, 32}},
+			{Pages: []int{60}, Example: []int{60}},
+		},
+		PagesWithoutRules: []int{40, 41},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Rules are written with the delimiter the input uses
+	arrows := lintRules(rules, nil, arrowFormat)
+	if arrows.Duplicates[0].Rule != "11->12" || arrows.Redundant[0].Rule != "10->12" ||
+		arrows.Contradictions[0] != [2]string{"20->21", "21->20"} {
+		t.Errorf("expected the rules to be written with ->, got %+v", arrows)
+	}
+
+	// Every rule of the example follows from the rules between neighbouring pages of its only valid order
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lintRules(rules, printOrders, puzzleFormat); len(got.Redundant) != 15 {
+		t.Errorf("expected 15 of the 21 example rules to be redundant, got %d", len(got.Redundant))
+	}
+
+	// Leaving out every redundant rule at once keeps every page before the same pages, even though all the pages
+	// of the puzzle input are on a cycle together
+	rules, printOrders, err = parseInputFile("input.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := depgraph.FromRules(rules)
+	reduced := depgraph.FromRules(rules)
+	redundant := lintRules(rules, printOrders, puzzleFormat).Redundant
+	if len(redundant) == 0 {
+		t.Fatalf("expected some of the puzzle rules to be redundant")
+	}
+	for _, rule := range redundant {
+		var page, later int
+		if _, err := fmt.Sscanf(rule.Rule, "%d|%d", &page, &later); err != nil {
+			t.Fatal(err)
+		}
+		reduced.RemoveEdge(page, later)
+	}
+	for _, page := range full.Nodes() {
+		want, got := full.Reachable(page), reduced.Reachable(page)
+		sort.Ints(want)
+		sort.Ints(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("page %d: reaches %v without the redundant rules, want %v", page, got, want)
+		}
+	}
+}
+
+func TestCountOrderings(t *testing.T) {
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := depgraph.FromRules(rules)
+
+	// Every example order has a rule for each pair of pages, so there is only one way to order each of them
+	for _, order := range printOrders {
+		if c, err := countPageOrderings(order, graph, 2); err != nil || c.Count != 1 || !c.Unique || len(c.Listed) != 1 {
+			t.Errorf("order %v: expected a single valid order, got %+v (%v)", order, c, err)
+		}
+	}
+
+	// Pages without any rules between them can go in any order
+	if count, err := countOrderings([]int{1, 2, 3, 4, 5}, graph); err != nil || count != 120 {
+		t.Errorf("expected 120 orders of pages without rules, got %d (%v)", count, err)
+	}
+
+	// Contradicting rules leave nothing to list, which shouldn't mean trying every order of the other pages first
+	cyclic := depgraph.FromRules(map[int][]int{1: {2}, 2: {1}})
+	pages := []int{1, 2, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}
+	if c, err := countPageOrderings(pages, cyclic, 1); err != nil || c.Count != 0 || len(c.Listed) != 0 {
+		t.Errorf("expected no orders of pages with contradicting rules, got %+v (%v)", c, err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 300; trial++ {
+		rules, _ := randomInput(rng, 0, rng.Float64())
+		graph := depgraph.FromRules(rules)
+		pages := rng.Perm(90)[:1+rng.Intn(7)]
+		for i := range pages {
+			pages[i] += 10
+		}
+
+		want := uint64(0)
+		permute(append([]int(nil), pages...), 0, func(candidate []int) {
+			if validatePrintOrder(candidate, rules).Valid {
+				want++
+			}
+		})
+
+		c, err := countPageOrderings(pages, graph, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Count != want || c.Unique != (want == 1) {
+			t.Fatalf("pages %v: got %d orders (unique %t), want %d", pages, c.Count, c.Unique, want)
+		}
+
+		seen := make(map[string]bool)
+		for _, listed := range c.Listed {
+			if !validatePrintOrder(listed, rules).Valid || seen[fmt.Sprint(listed)] {
+				t.Fatalf("pages %v: listed %v more than once or breaking the rules", pages, listed)
+			}
+			seen[fmt.Sprint(listed)] = true
+		}
+		if want > 3 {
+			want = 3
+		}
+		if uint64(len(c.Listed)) != want {
+			t.Fatalf("pages %v: expected %d orders listed, got %v", pages, want, c.Listed)
+		}
+	}
+}
+
+func TestExportRules(t *testing.T) {
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Order 4 (75,97,47,61,53) breaks the rule 97|75
+	var dot strings.Builder
+	if err := exportRules(&dot, "dot", rules, printOrders, 4); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `"97" -> "75" [color=red, penwidth=2];`) ||
+		strings.Count(dot.String(), "color=red") != 1 {
+		t.Errorf("expected only the rule 97|75 to be highlighted, got\n%s", dot.String())
+	}
+	if strings.Contains(dot.String(), `"29"`) {
+		t.Errorf("expected only the pages of order 4, got\n%s", dot.String())
+	}
+
+	var mermaid strings.Builder
+	if err := exportRules(&mermaid, "mermaid", rules, printOrders, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(mermaid.String(), "-->"); got != 21 {
+		t.Errorf("expected all 21 rules, got %d", got)
+	}
+
+	if err := exportRules(io.Discard, "dot", rules, printOrders, 7); err == nil {
+		t.Errorf("expected an error for an order that doesn't exist")
+	}
+}
+
+func TestPrintQueue(t *testing.T) {
+	rules, printOrders, err := parseInputFile("input_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := newPrintQueue(rules, printOrders, puzzleFormat)
+
+	var out strings.Builder
+	script := "remove-rule 97|75\nadd-order 13,29\nadd-rule 53|61\nremove-order 3\n"
+	if err := runQueueCommands(q, strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := `remove-rule 97|75: order 4 (75,97,47,61,53) is now valid, the sum of valid middles is 190
+add-order 13,29: order 7 (13,29) is now invalid, the sum of valid middles is 190
+add-rule 53|61: order 1 (75,47,61,53,29) is now invalid, order 2 (97,61,53,29,13) is now invalid, order 4 (75,97,47,61,53) is now invalid, the sum of valid middles is 29
+remove-order 3: the sum of valid middles is 0
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+
+	for _, command := range []string{"remove-rule 1|2", "remove-order 3", "add-rule 1", "add-order 1,x", "print"} {
+		if _, err := applyQueueCommand(q, command); err == nil {
+			t.Errorf("expected %q to fail", command)
+		}
+	}
+
+	// Commands and errors are written in the same format as the input
+	q = newPrintQueue(rules, printOrders, arrowFormat)
+	if _, err := applyQueueCommand(q, "add-order 1;2"); err != nil {
+		t.Fatal(err)
+	}
+	change, err := applyQueueCommand(q, "add-rule 2 -> 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(change.Changed) != 1 || change.Changed[0].ID != 7 || change.Changed[0].Valid {
+		t.Errorf("expected the new rule to make order 7 invalid, got %+v", change.Changed)
+	}
+	if _, err := applyQueueCommand(q, "remove-rule 1 -> 2"); err == nil || !strings.Contains(err.Error(), "1->2") {
+		t.Errorf("expected the rule in the error to be written with ->, got %v", err)
+	}
+}
+
+func TestPrintQueueMatchesFullValidation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	rules, printOrders := randomInput(rng, 100, 0.3)
+	q := newPrintQueue(rules, printOrders, puzzleFormat)
+
+	// Pick rules from the pages of the orders, so that plenty of them apply
+	randomRule := func() (int, int) {
+		order := q.orders[1+rng.Intn(q.nextID-1)]
+		if order == nil {
+			order = printOrders[0]
+		}
+		return order[rng.Intn(len(order))], order[rng.Intn(len(order))]
+	}
+
+	for step := 0; step < 300; step++ {
+		valid := make(map[int]bool, len(q.orders))
+		for id, order := range q.orders {
+			valid[id] = validatePrintOrder(order, q.rules).Valid
+		}
+
+		var change queueChange
+		switch rng.Intn(4) {
+		case 0:
+			change = q.AddRule(randomRule())
+		case 1:
+			page, later := randomRule()
+			if len(q.rules[page]) > 0 {
+				later = q.rules[page][rng.Intn(len(q.rules[page]))]
+			}
+			change, _ = q.RemoveRule(page, later)
+		case 2:
+			_, change = q.AddOrder(printOrders[rng.Intn(len(printOrders))])
+		case 3:
+			change, _ = q.RemoveOrder(1 + rng.Intn(q.nextID-1))
+		}
+
+		sum := 0
+		reported := make(map[int]bool)
+		for _, c := range change.Changed {
+			reported[c.ID] = true
+		}
+		for id, order := range q.orders {
+			result := validatePrintOrder(order, q.rules)
+			if result.Valid {
+				sum += result.Middle
+			}
+			if was, ok := valid[id]; ok && was != result.Valid && !reported[id] {
+				t.Fatalf("step %d: order %d changed validity without being reported", step, id)
+			}
+		}
+		if sum != q.sumOfValidMiddles || (len(change.Changed) > 0 && change.SumOfValidMiddles != sum) {
+			t.Fatalf("step %d: the queue has a sum of %d, want %d", step, q.sumOfValidMiddles, sum)
+		}
+	}
+}
+
+// applyMove takes the page out of the order and puts it back where the move says.
+func applyMove(order []int, move pageMove) []int {
+	rest := make([]int, 0, len(order))
+	for _, page := range order {
+		if page != move.Page {
+			rest = append(rest, page)
+		}
+	}
+
+	moved := make([]int, 0, len(order))
+	if move.ToFront {
+		moved = append(moved, move.Page)
+	}
+	for _, page := range rest {
+		moved = append(moved, page)
+		if !move.ToFront && page == *move.After {
+			moved = append(moved, move.Page)
+		}
+	}
+	return moved
+}
+
+// inversions counts the pairs of pages that are the other way round in the two orders.
+func inversions(order []int, other []int) int {
+	position := make(map[int]int, len(other))
+	for i, page := range other {
+		position[page] = i
+	}
+
+	count := 0
+	for i := range order {
+		for j := i + 1; j < len(order); j++ {
+			if position[order[i]] > position[order[j]] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// bruteForceRepair tries every order of the pages. The fewest moves is the number of pages outside the longest
+// run of pages that a valid order keeps in the same relative order.
+func bruteForceRepair(order []int, rules map[int][]int) (int, int) {
+	moves, swaps := len(order), len(order)*len(order)
+	permute(append([]int(nil), order...), 0, func(candidate []int) {
+		if !validatePrintOrder(candidate, rules).Valid {
+			return
+		}
+
+		if count := inversions(order, candidate); count < swaps {
+			swaps = count
+		}
+		if count := len(order) - longestCommonSubsequence(order, candidate); count < moves {
+			moves = count
+		}
+	})
+	return moves, swaps
+}
+
+func permute(pages []int, from int, visit func([]int)) {
+	if from == len(pages) {
+		visit(pages)
+		return
+	}
+	for i := from; i < len(pages); i++ {
+		pages[from], pages[i] = pages[i], pages[from]
+		permute(pages, from+1, visit)
+		pages[from], pages[i] = pages[i], pages[from]
+	}
+}
+
+func longestCommonSubsequence(a []int, b []int) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				lengths[i][j] = lengths[i-1][j-1] + 1
+			case lengths[i-1][j] > lengths[i][j-1]:
+				lengths[i][j] = lengths[i-1][j]
+			default:
+				lengths[i][j] = lengths[i][j-1]
+			}
+		}
+	}
+	return lengths[len(a)][len(b)]
+}
+
+// sortByRules puts the pages of the order in an order that follows the rules, which must not contradict each other.
+func sortByRules(t *testing.T, order []int, rules *depgraph.Graph[int]) {
+	sorted, err := sortPrintOrder(order, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(order, sorted)
+}
+
+// withRule returns a copy of the rules with an extra rule added.
+func withRule(rules map[int][]int, page int, later int) map[int][]int {
+	copied := make(map[int][]int, len(rules)+1)
+	for p, laterPages := range rules {
+		copied[p] = append([]int(nil), laterPages...)
+	}
+	copied[page] = append(copied[page], later)
+	return copied
+}
+
+// mapValid is the original validity check from before validatePrintOrder reported violations, with a map of the
+// pages seen so far that stops at the first broken rule. It is the fair baseline for ruleIndex.valid, since both only
+// answer whether the order is valid.
+func mapValid(printOrder []int, rules map[int][]int) bool {
+	previous := make(map[int]bool, len(printOrder))
+	for _, pageNum := range printOrder {
+		for _, rule := range rules[pageNum] {
+			if previous[rule] {
+				return false
+			}
+		}
+		previous[pageNum] = true
+	}
+	return true
+}
+
+func BenchmarkValidatePrintOrder(b *testing.B) {
+	rules, printOrders, err := parseInputFile("input.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	type benchmarkInput struct {
+		name        string
+		rules       map[int][]int
+		printOrders [][]int
+	}
+
+	inputs := []benchmarkInput{{"input", rules, printOrders}}
+	for _, orders := range []int{10000, 100000} {
+		rules, printOrders := randomInput(rand.New(rand.NewSource(1)), orders, 0.5)
+		inputs = append(inputs, benchmarkInput{fmt.Sprintf("random-%d", orders), rules, printOrders})
+	}
+
+	for _, input := range inputs {
+		b.Run(input.name+"/map", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, order := range input.printOrders {
+					mapValid(order, input.rules)
+				}
+			}
+		})
+
+		b.Run(input.name+"/index", func(b *testing.B) {
+			index := newRuleIndex(input.rules)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, order := range input.printOrders {
+					index.valid(order)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+
+	"common/depgraph"
+)
+
+// orderings describes how many ways the rules allow a set of pages to be ordered.
+type orderings struct {
+	Pages []int `json:"pages"`
+	// Count is the number of orders of the pages that follow every rule, which is 0 if the rules for them contradict
+	// each other
+	Count uint64 `json:"count"`
+	// Unique is true when exactly one order follows the rules, so the correct order of part 2 is well defined
+	Unique bool `json:"unique"`
+	// Listed holds the valid orders, up to the number asked for
+	Listed [][]int `json:"listed,omitempty"`
+}
+
+// pagePositions maps each page to where it is in the set, and makes sure no page is in it twice.
+func pagePositions(pages []int) (map[int]int, error) {
+	if len(pages) > 64 {
+		return nil, fmt.Errorf("unable to order %v with more than 64 pages", pages)
+	}
+
+	position := make(map[int]int, len(pages))
+	for i, page := range pages {
+		if _, ok := position[page]; ok {
+			return nil, fmt.Errorf("unable to order %v as page %d is in it twice", pages, page)
+		}
+		position[page] = i
+	}
+	return position, nil
+}
+
+// countOrderings counts the orders of the pages that follow the rules, which are the linear extensions of the
+// rules between them. Building an order a page at a time, the pages placed so far are always a set closed under
+// the rules, so the number of ways to place each such set is the sum of the ways to place it without each page
+// that could have come last. The sets are kept as bitmasks, and only the ones that can actually be reached are
+// looked at, so pages with a rule for every pair, like the puzzle's, only ever have one set of each size.
+func countOrderings(pages []int, rules *depgraph.Graph[int]) (uint64, error) {
+	position, err := pagePositions(pages)
+	if err != nil {
+		return 0, err
+	}
+
+	predecessors := predecessorMasks(pages, rules.Subgraph(pages), position)
+	ways := map[uint64]uint64{0: 1}
+	layer := []uint64{0}
+	for size := 0; size < len(pages); size++ {
+		next := make([]uint64, 0)
+		grownWays := make(map[uint64]uint64)
+		for _, placed := range layer {
+			for i := range pages {
+				bit := uint64(1) << i
+				if placed&bit != 0 || predecessors[i]&^placed != 0 {
+					continue
+				}
+
+				grown := placed | bit
+				if _, ok := grownWays[grown]; !ok {
+					next = append(next, grown)
+				}
+				sum, carry := bits.Add64(grownWays[grown], ways[placed], 0)
+				if carry != 0 {
+					return 0, fmt.Errorf("unable to count the orders of %v, there are more than fit in 64 bits", pages)
+				}
+				grownWays[grown] = sum
+			}
+		}
+
+		if len(grownWays) > maxDownSets {
+			return 0, fmt.Errorf("unable to count the orders of %v, the rules allow too many partial orders", pages)
+		}
+		ways, layer = grownWays, next
+	}
+
+	// If the rules contradict each other the pages on the cycle can never be placed, and no set has every page
+	var count uint64
+	for _, placed := range layer {
+		count += ways[placed]
+	}
+	return count, nil
+}
+
+// listOrderings returns up to limit orders of the pages that follow the rules. They are found by trying the pages
+// that could come next in the order they are in the set, so the first is the one TopologicalSort gives. Every
+// partial order can be finished when the rules don't contradict each other, but when they do none can, and every
+// partial order of the other pages is tried, so check there is at least one order first.
+func listOrderings(pages []int, rules *depgraph.Graph[int], limit int) ([][]int, error) {
+	position, err := pagePositions(pages)
+	if err != nil {
+		return nil, err
+	}
+
+	predecessors := predecessorMasks(pages, rules.Subgraph(pages), position)
+	listed := make([][]int, 0)
+	order := make([]int, 0, len(pages))
+
+	var extend func(placed uint64)
+	extend = func(placed uint64) {
+		if len(order) == len(pages) {
+			listed = append(listed, append([]int(nil), order...))
+			return
+		}
+		for i, page := range pages {
+			bit := uint64(1) << i
+			if placed&bit != 0 || predecessors[i]&^placed != 0 {
+				continue
+			}
+
+			order = append(order, page)
+			extend(placed | bit)
+			order = order[:len(order)-1]
+			if len(listed) >= limit {
+				return
+			}
+		}
+	}
+
+	if limit > 0 {
+		extend(0)
+	}
+	return listed, nil
+}
+
+// uniqueOrdering reports whether exactly one order of the pages follows the rules, which is when every step of
+// Kahn's algorithm has exactly one page to pick from. Unlike countOrderings it works for any number of pages.
+func uniqueOrdering(pages []int, rules *depgraph.Graph[int]) bool {
+	sub := rules.Subgraph(pages)
+	sorted, err := sub.TopologicalSort()
+	if err != nil {
+		return false
+	}
+
+	// There is only one choice at each step when each page must come directly before the next
+	for i := 1; i < len(sorted); i++ {
+		if !sub.HasEdge(sorted[i-1], sorted[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// countPageOrderings works out the orderings of the pages, listing up to limit of them.
+func countPageOrderings(pages []int, rules *depgraph.Graph[int], limit int) (orderings, error) {
+	count, err := countOrderings(pages, rules)
+	if err != nil {
+		return orderings{}, err
+	}
+
+	// With no valid orders there is nothing to list, and searching for them could take factorial time
+	listed := make([][]int, 0)
+	if count > 0 {
+		listed, err = listOrderings(pages, rules, limit)
+		if err != nil {
+			return orderings{}, err
+		}
+	}
+	return orderings{Pages: pages, Count: count, Unique: uniqueOrdering(pages, rules), Listed: listed}, nil
+}
+
+// runOrderings implements the orderings subcommand, which counts the valid orders of the pages of every print
+// order, or of a set of pages given on the command line.
+func runOrderings(args []string, source inputSource) error {
+	flags := flag.NewFlagSet("orderings", flag.ExitOnError)
+	input := flags.String("input", source.filename, "file to read the rules and print orders from")
+	format := flags.String("format", "text", "write the counts as text or json")
+	limit := flags.Int("list", 0, "list up to this many valid orders for each set of pages")
+	pageList := flags.String("pages", "", "comma separated pages to count the orders of, instead of every print order")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		debugOutput = os.Stderr
+	}
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*input, source.format, parseIntID)
+	if err != nil {
+		return err
+	}
+
+	if *pageList != "" {
+		pages := make([]int, 0)
+		for _, raw := range strings.Split(*pageList, ",") {
+			page, err := parseIntID(strings.TrimSpace(raw))
+			if err != nil {
+				return err
+			}
+			pages = append(pages, page)
+		}
+		printOrders = [][]int{pages}
+	}
+
+	graph := depgraph.FromRules(orderRules)
+	counts := make([]orderings, 0, len(printOrders))
+	for i, order := range printOrders {
+		c, err := countPageOrderings(order, graph, *limit)
+		if err != nil {
+			return fmt.Errorf("unable to count the orders of print order %d due to: %w", i+1, err)
+		}
+		counts = append(counts, c)
+	}
+	return writeOrderings(os.Stdout, *format, counts)
+}
+
+// writeOrderings writes the counts in the named format, which is text or json.
+func writeOrderings(w io.Writer, format string, counts []orderings) error {
+	switch format {
+	case "text":
+		out := bufio.NewWriter(w)
+		unique := 0
+		for i, c := range counts {
+			switch {
+			case c.Count == 0:
+				fmt.Fprintf(out, "Order %d (%s) can't be ordered, its rules contradict each other\n", i+1, joinPages(c.Pages))
+			case c.Unique:
+				unique++
+				fmt.Fprintf(out, "Order %d (%s) has a single valid order\n", i+1, joinPages(c.Pages))
+			default:
+				fmt.Fprintf(out, "Order %d (%s) has %d valid orders\n", i+1, joinPages(c.Pages), c.Count)
+			}
+			for _, listed := range c.Listed {
+				fmt.Fprintf(out, "  %s\n", joinPages(listed))
+			}
+		}
+		fmt.Fprintf(out, "%d of %d orders have a single valid order\n", unique, len(counts))
+		return out.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(counts)
+	}
+	return fmt.Errorf("unknown orderings format %q, expected text or json", format)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// printQueue holds rules and print orders that change over time. Every order's validity is kept up to date, and a
+// change to the rules only re-validates the orders it can affect, which are the ones with both pages of the rule.
+type printQueue struct {
+	rules map[int][]int
+	// orders holds the queued print orders by ID, which count up from 1 in the order they are added
+	orders map[int][]int
+	nextID int
+	valid  map[int]bool
+	// ordersWithPage holds the IDs of the orders each page is in
+	ordersWithPage    map[int]map[int]bool
+	sumOfValidMiddles int
+	// format is how rules and orders are written in commands and errors
+	format inputFormat
+}
+
+// orderChange is a print order whose validity changed.
+type orderChange struct {
+	ID    int
+	Order []int
+	// Valid is whether the order is valid now
+	Valid bool
+}
+
+// queueChange is the outcome of changing the queue.
+type queueChange struct {
+	// Changed lists the orders whose validity changed by ID. An added order is always listed, with the validity it
+	// starts with, and a removed order never is.
+	Changed           []orderChange
+	SumOfValidMiddles int
+}
+
+// newPrintQueue builds a queue from rules and print orders in the form parsed from the input, which was written in
+// the format. The orders get the IDs 1, 2, 3... in the order they are provided.
+func newPrintQueue(rules map[int][]int, printOrders [][]int, format inputFormat) *printQueue {
+	q := &printQueue{
+		rules:          make(map[int][]int, len(rules)),
+		orders:         make(map[int][]int, len(printOrders)),
+		nextID:         1,
+		valid:          make(map[int]bool, len(printOrders)),
+		ordersWithPage: make(map[int]map[int]bool),
+		format:         format,
+	}
+	for page, laterPages := range rules {
+		q.rules[page] = append([]int(nil), laterPages...)
+	}
+	for _, order := range printOrders {
+		q.AddOrder(order)
+	}
+	return q
+}
+
+// AddRule adds the rule that page must be printed before later, and re-validates the orders with both pages.
+func (q *printQueue) AddRule(page int, later int) queueChange {
+	q.rules[page] = append(q.rules[page], later)
+	return q.revalidate(q.affectedBy(page, later))
+}
+
+// RemoveRule removes the rule that page must be printed before later, and re-validates the orders with both pages.
+// If the rule was added more than once, only one of them is removed.
+func (q *printQueue) RemoveRule(page int, later int) (queueChange, error) {
+	laterPages := q.rules[page]
+	for i, candidate := range laterPages {
+		if candidate != later {
+			continue
+		}
+
+		q.rules[page] = append(laterPages[:i:i], laterPages[i+1:]...)
+		if len(q.rules[page]) == 0 {
+			delete(q.rules, page)
+		}
+		return q.revalidate(q.affectedBy(page, later)), nil
+	}
+	return queueChange{}, fmt.Errorf("there is no rule %s to remove", q.format.formatRule(page, later))
+}
+
+// AddOrder queues a print order, returning its ID along with its validity.
+func (q *printQueue) AddOrder(order []int) (int, queueChange) {
+	id := q.nextID
+	q.nextID++
+	q.orders[id] = append([]int(nil), order...)
+	for _, page := range order {
+		if q.ordersWithPage[page] == nil {
+			q.ordersWithPage[page] = make(map[int]bool)
+		}
+		q.ordersWithPage[page][id] = true
+	}
+
+	valid := validatePrintOrder(order, q.rules).Valid
+	q.setValid(id, valid)
+	return id, q.change([]orderChange{{ID: id, Order: q.orders[id], Valid: valid}})
+}
+
+// RemoveOrder removes the print order with the ID from the queue.
+func (q *printQueue) RemoveOrder(id int) (queueChange, error) {
+	order, ok := q.orders[id]
+	if !ok {
+		return queueChange{}, fmt.Errorf("there is no print order %d to remove", id)
+	}
+
+	q.setValid(id, false)
+	delete(q.valid, id)
+	delete(q.orders, id)
+	for _, page := range order {
+		delete(q.ordersWithPage[page], id)
+		if len(q.ordersWithPage[page]) == 0 {
+			delete(q.ordersWithPage, page)
+		}
+	}
+	return q.change(nil), nil
+}
+
+// affectedBy returns the IDs of the orders a rule between the two pages applies to, in increasing order.
+func (q *printQueue) affectedBy(page int, later int) []int {
+	affected := make([]int, 0)
+	for id := range q.ordersWithPage[page] {
+		if q.ordersWithPage[later][id] {
+			affected = append(affected, id)
+		}
+	}
+	sort.Ints(affected)
+	return affected
+}
+
+// revalidate validates the orders again, and reports the ones whose validity changed.
+func (q *printQueue) revalidate(ids []int) queueChange {
+	changed := make([]orderChange, 0)
+	for _, id := range ids {
+		valid := validatePrintOrder(q.orders[id], q.rules).Valid
+		if valid != q.valid[id] {
+			q.setValid(id, valid)
+			changed = append(changed, orderChange{ID: id, Order: q.orders[id], Valid: valid})
+		}
+	}
+	return q.change(changed)
+}
+
+// setValid records whether the order is valid, keeping the sum of valid middles up to date.
+func (q *printQueue) setValid(id int, valid bool) {
+	if q.valid[id] == valid {
+		q.valid[id] = valid
+		return
+	}
+
+	order := q.orders[id]
+	middle := 0
+	if len(order) > 0 {
+		middle = order[(len(order)-1)/2]
+	}
+	if valid {
+		q.sumOfValidMiddles += middle
+	} else {
+		q.sumOfValidMiddles -= middle
+	}
+	q.valid[id] = valid
+}
+
+func (q *printQueue) change(changed []orderChange) queueChange {
+	if changed == nil {
+		changed = make([]orderChange, 0)
+	}
+	return queueChange{Changed: changed, SumOfValidMiddles: q.sumOfValidMiddles}
+}
+
+// runQueue implements the queue subcommand, which loads the input into a print queue and then changes it with the
+// commands read from a script, one per line:
+//
+//	add-rule 47|53
+//	remove-rule 47|53
+//	add-order 75,47,61
+//	remove-order 3
+//
+// Rules and orders in the commands are written in the same format as the input. After each command it prints the
+// orders that changed validity, and the new sum of valid middles.
+func runQueue(args []string, source inputSource) error {
+	flags := flag.NewFlagSet("queue", flag.ExitOnError)
+	input := flags.String("input", source.filename, "file to read the starting rules and print orders from")
+	script := flags.String("script", "", "file to read the commands from (standard input by default)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*input, source.format, parseIntID)
+	if err != nil {
+		return err
+	}
+	q := newPrintQueue(orderRules, printOrders, source.format)
+	fmt.Printf("Queued %d print orders, the sum of valid middles is %d\n", len(printOrders), q.sumOfValidMiddles)
+
+	commands := io.Reader(os.Stdin)
+	if *script != "" {
+		file, err := os.Open(*script)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		commands = file
+	}
+
+	return runQueueCommands(q, commands, os.Stdout)
+}
+
+// runQueueCommands applies each command to the queue, writing what changed.
+func runQueueCommands(q *printQueue, commands io.Reader, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	scanner := bufio.NewScanner(commands)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		change, err := applyQueueCommand(q, line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		fmt.Fprintf(out, "%s: ", line)
+		for _, c := range change.Changed {
+			validity := "invalid"
+			if c.Valid {
+				validity = "valid"
+			}
+			fmt.Fprintf(out, "order %d (%s) is now %s, ", c.ID, joinPages(c.Order), validity)
+		}
+		fmt.Fprintf(out, "the sum of valid middles is %d\n", change.SumOfValidMiddles)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading commands due to: %w", err)
+	}
+	return nil
+}
+
+// applyQueueCommand parses a single command, with rules and orders written in the queue's format, and applies it to
+// the queue.
+func applyQueueCommand(q *printQueue, line string) (queueChange, error) {
+	command, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+
+	switch command {
+	case "add-rule", "remove-rule":
+		rawPages := strings.Split(argument, q.format.RuleDelimiter)
+		if len(rawPages) != 2 {
+			return queueChange{}, fmt.Errorf("expected a rule like 47%s53, got %q", q.format.RuleDelimiter, argument)
+		}
+		page, err := parseIntID(strings.TrimSpace(rawPages[0]))
+		if err != nil {
+			return queueChange{}, err
+		}
+		later, err := parseIntID(strings.TrimSpace(rawPages[1]))
+		if err != nil {
+			return queueChange{}, err
+		}
+
+		if command == "add-rule" {
+			return q.AddRule(page, later), nil
+		}
+		return q.RemoveRule(page, later)
+	case "add-order":
+		order := make([]int, 0)
+		for _, raw := range strings.Split(argument, q.format.OrderDelimiter) {
+			page, err := parseIntID(strings.TrimSpace(raw))
+			if err != nil {
+				return queueChange{}, err
+			}
+			order = append(order, page)
+		}
+		_, change := q.AddOrder(order)
+		return change, nil
+	case "remove-order":
+		id, err := strconv.Atoi(argument)
+		if err != nil {
+			return queueChange{}, fmt.Errorf("unable to parse print order ID %q as int due to: %w", argument, err)
+		}
+		return q.RemoveOrder(id)
+	}
+	return queueChange{}, fmt.Errorf("unknown command %q, expected add-rule, remove-rule, add-order or remove-order",
+		command)
+}
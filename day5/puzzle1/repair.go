@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+
+	"common/depgraph"
+)
+
+// maxDownSets limits how many sets of already placed pages are looked at when building valid orders a page at a
+// time. The puzzle has a rule for every pair of pages in an order, so there is only one valid order and one set per
+// length, but orders with few rules have a huge number of valid orders to pick from.
+const maxDownSets = 1 << 20
+
+// pageMove takes a page out of an order and puts it back straight after another page, or at the front.
+type pageMove struct {
+	Page int `json:"page"`
+	// After is the page it is put after, or nil if it goes to the front. It is a pointer so that page 0 is still
+	// written out in JSON.
+	After   *int `json:"after,omitempty"`
+	ToFront bool `json:"toFront,omitempty"`
+}
+
+func (m pageMove) String() string {
+	if m.ToFront {
+		return fmt.Sprintf("move %d to the front", m.Page)
+	}
+	return fmt.Sprintf("move %d after %d", m.Page, *m.After)
+}
+
+// repair describes the smallest edits that turn an invalid print order into a valid one, counted in two ways.
+type repair struct {
+	// Moves are the fewest pages to take out and put back elsewhere, made one after the other
+	Moves []pageMove `json:"moves"`
+	// MovedOrder is the valid order the moves end up with
+	MovedOrder []int `json:"movedOrder"`
+	// Swaps is the fewest swaps of neighbouring pages that make the order valid
+	Swaps int `json:"swaps"`
+	// SwappedOrder is the valid order those swaps end up with
+	SwappedOrder []int `json:"swappedOrder"`
+}
+
+// repairPrintOrder finds the smallest edits that make the print order follow the rules.
+//
+// A page that isn't moved keeps its place relative to the other pages that aren't moved, so the pages left alone
+// must be a set where no page has to come before a page printed ahead of it, directly or through other pages. The
+// "has to come before a page printed ahead of it" relation between positions is a partial order, since it is
+// transitive, and a set of positions with no two of them related is an antichain of it. The largest antichain is
+// found with Dilworth's and König's theorems from a maximum matching, and every other page is moved.
+//
+// Each swap of neighbouring pages fixes one pair of pages that are the wrong way round, so the fewest swaps is the
+// fewest pairs that any valid order has the other way round from the print order. That is found by building the
+// valid order one page at a time, keeping the cheapest way to reach each set of placed pages.
+func repairPrintOrder(printOrder []int, rules *depgraph.Graph[int]) (repair, error) {
+	if len(printOrder) > 64 {
+		return repair{}, fmt.Errorf("unable to repair print order %v with more than 64 pages", printOrder)
+	}
+
+	position := make(map[int]int, len(printOrder))
+	for i, page := range printOrder {
+		if _, ok := position[page]; ok {
+			return repair{}, fmt.Errorf("unable to repair print order %v as page %d is in it twice", printOrder, page)
+		}
+		position[page] = i
+	}
+
+	sub := rules.Subgraph(printOrder)
+	if _, err := sub.TopologicalSort(); err != nil {
+		return repair{}, fmt.Errorf("unable to repair print order %v due to: %w", printOrder, err)
+	}
+
+	// mustPrecede[i] holds the positions of the pages the page at position i has to come before, directly or
+	// through other pages
+	mustPrecede := make([]uint64, len(printOrder))
+	for i, page := range printOrder {
+		for _, later := range sub.Reachable(page) {
+			mustPrecede[i] |= 1 << position[later]
+		}
+	}
+
+	moves, movedOrder, err := fewestMoves(printOrder, sub, mustPrecede)
+	if err != nil {
+		return repair{}, err
+	}
+
+	swaps, swappedOrder, err := fewestSwaps(printOrder, sub, position)
+	if err != nil {
+		return repair{}, err
+	}
+
+	return repair{Moves: moves, MovedOrder: movedOrder, Swaps: swaps, SwappedOrder: swappedOrder}, nil
+}
+
+// fewestMoves finds the largest set of pages that can stay where they are, and the moves for the rest.
+func fewestMoves(printOrder []int, sub *depgraph.Graph[int], mustPrecede []uint64) ([]pageMove, []int, error) {
+	n := len(printOrder)
+
+	// Position i is before j in the partial order when i is printed first but the page at j has to come before it
+	before := func(i, j int) bool {
+		return i < j && mustPrecede[j]&(1<<i) != 0
+	}
+
+	// Maximum matching from the left copy of each position to the right copy of a later one, using augmenting paths
+	matchLeft, matchRight := make([]int, n), make([]int, n)
+	for i := range matchLeft {
+		matchLeft[i], matchRight[i] = -1, -1
+	}
+	var augment func(i int, tried []bool) bool
+	augment = func(i int, tried []bool) bool {
+		for j := 0; j < n; j++ {
+			if !before(i, j) || tried[j] {
+				continue
+			}
+			tried[j] = true
+			if matchRight[j] == -1 || augment(matchRight[j], tried) {
+				matchLeft[i], matchRight[j] = j, i
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < n; i++ {
+		augment(i, make([]bool, n))
+	}
+
+	// Following alternating paths from the unmatched left copies gives König's minimum vertex cover: the left
+	// copies that aren't reached and the right copies that are. The positions with neither copy in the cover are a
+	// largest antichain.
+	leftReached, rightReached := make([]bool, n), make([]bool, n)
+	queue := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if matchLeft[i] == -1 {
+			leftReached[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for j := 0; j < n; j++ {
+			if !before(i, j) || rightReached[j] {
+				continue
+			}
+			rightReached[j] = true
+			if next := matchRight[j]; next != -1 && !leftReached[next] {
+				leftReached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Chaining the pages that stay together in their current order, on top of the rules, gives a valid order with
+	// every other page wherever the rules allow
+	kept := make(map[int]bool, n)
+	chained := depgraph.New[int]()
+	for _, page := range printOrder {
+		chained.AddNode(page)
+	}
+	for _, edge := range sub.Edges() {
+		chained.AddEdge(edge[0], edge[1])
+	}
+	previous, started := 0, false
+	for i, page := range printOrder {
+		if !leftReached[i] || rightReached[i] {
+			continue
+		}
+		kept[page] = true
+		if started {
+			chained.AddEdge(previous, page)
+		}
+		previous, started = page, true
+	}
+
+	movedOrder, err := chained.TopologicalSort()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to keep the unmoved pages of %v in order due to: %w", printOrder, err)
+	}
+
+	// Moving the pages in the order they end up in, each straight after the page before it, leaves every page where
+	// it belongs, as the pages it is put after are already in place
+	moves := make([]pageMove, 0, n-len(kept))
+	for i, page := range movedOrder {
+		if kept[page] {
+			continue
+		}
+		if i == 0 {
+			moves = append(moves, pageMove{Page: page, ToFront: true})
+		} else {
+			after := movedOrder[i-1]
+			moves = append(moves, pageMove{Page: page, After: &after})
+		}
+	}
+	return moves, movedOrder, nil
+}
+
+// fewestSwaps finds the valid order with the fewest pairs of pages the other way round from the print order, which
+// is the number of swaps of neighbouring pages needed to reach it.
+func fewestSwaps(printOrder []int, sub *depgraph.Graph[int], position map[int]int) (int, []int, error) {
+	n := len(printOrder)
+
+	predecessors := predecessorMasks(printOrder, sub, position)
+
+	type state struct {
+		cost int
+		// last is the position of the page placed last, and parent the set of pages placed before it
+		last   int
+		parent uint64
+	}
+
+	// Every set of placed pages of the same size is built from the sets one page smaller
+	best := map[uint64]state{0: {}}
+	layer := []uint64{0}
+	for size := 0; size < n; size++ {
+		next := make([]uint64, 0)
+		for _, placed := range layer {
+			for i := 0; i < n; i++ {
+				bit := uint64(1) << i
+				if placed&bit != 0 || predecessors[i]&^placed != 0 {
+					continue
+				}
+
+				// Placing the page next puts it ahead of every unplaced page that is printed before it
+				cost := best[placed].cost + bits.OnesCount64(^placed&(bit-1))
+				grown := placed | bit
+				if existing, ok := best[grown]; !ok {
+					next = append(next, grown)
+				} else if existing.cost <= cost {
+					continue
+				}
+				best[grown] = state{cost: cost, last: i, parent: placed}
+			}
+		}
+
+		if len(best) > maxDownSets {
+			return 0, nil, fmt.Errorf("unable to find the fewest swaps for print order %v, the rules allow too many orders",
+				printOrder)
+		}
+		layer = next
+	}
+
+	all := uint64(1)<<n - 1
+	if n == 64 {
+		all = ^uint64(0)
+	}
+	swappedOrder := make([]int, n)
+	for placed, i := all, n-1; placed != 0; i-- {
+		s := best[placed]
+		swappedOrder[i] = printOrder[s.last]
+		placed = s.parent
+	}
+	return best[all].cost, swappedOrder, nil
+}
+
+// predecessorMasks returns, for each page, the set of positions of the pages that have to come directly before it,
+// so a page can be placed once the set of pages already placed covers its mask.
+func predecessorMasks(pages []int, sub *depgraph.Graph[int], position map[int]int) []uint64 {
+	predecessors := make([]uint64, len(pages))
+	for i, page := range pages {
+		for _, earlier := range sub.Predecessors(page) {
+			predecessors[i] |= 1 << position[earlier]
+		}
+	}
+	return predecessors
+}
+
+// orderRepair is the repair of a single invalid print order.
+type orderRepair struct {
+	// Number is the position of the order in the input, counting from 1
+	Number int   `json:"number"`
+	Order  []int `json:"order"`
+	repair
+}
+
+// runRepair implements the repair subcommand, which reports the smallest edits that make each invalid print
+// order valid.
+func runRepair(args []string, source inputSource) error {
+	flags := flag.NewFlagSet("repair", flag.ExitOnError)
+	input := flags.String("input", source.filename, "file to read the rules and print orders from")
+	format := flags.String("format", "text", "write the repairs as text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		debugOutput = os.Stderr
+	}
+
+	orderRules, printOrders, err := parseInputFileWithFormat(*input, source.format, parseIntID)
+	if err != nil {
+		return err
+	}
+
+	repairs, err := repairPrintOrders(printOrders, orderRules)
+	if err != nil {
+		return err
+	}
+	return writeRepairs(os.Stdout, *format, repairs)
+}
+
+// repairPrintOrders repairs every print order that breaks the rules.
+func repairPrintOrders(printOrders [][]int, rules map[int][]int) ([]orderRepair, error) {
+	graph := depgraph.FromRules(rules)
+	index := newRuleIndex(rules)
+
+	repairs := make([]orderRepair, 0)
+	for i, order := range printOrders {
+		if index.valid(order) {
+			continue
+		}
+
+		r, err := repairPrintOrder(order, graph)
+		if err != nil {
+			return nil, fmt.Errorf("unable to repair print order %d due to: %w", i+1, err)
+		}
+		repairs = append(repairs, orderRepair{Number: i + 1, Order: order, repair: r})
+	}
+	return repairs, nil
+}
+
+// writeRepairs writes the repairs in the named format, which is text or json.
+func writeRepairs(w io.Writer, format string, repairs []orderRepair) error {
+	switch format {
+	case "text":
+		out := bufio.NewWriter(w)
+		for _, r := range repairs {
+			pages := "pages"
+			if len(r.Moves) == 1 {
+				pages = "page"
+			}
+			fmt.Fprintf(out, "Order %d (%s) needs %d %s moved, giving %s:\n", r.Number, joinPages(r.Order),
+				len(r.Moves), pages, joinPages(r.MovedOrder))
+			for _, move := range r.Moves {
+				fmt.Fprintf(out, "  %s\n", move)
+			}
+
+			swaps := "swaps"
+			if r.Swaps == 1 {
+				swaps = "swap"
+			}
+			fmt.Fprintf(out, "  or %d %s of neighbouring pages, giving %s\n", r.Swaps, swaps, joinPages(r.SwappedOrder))
+		}
+		return out.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(repairs)
+	}
+	return fmt.Errorf("unknown repair format %q, expected text or json", format)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// orderReport is the validation result of a single print order.
+type orderReport struct {
+	// Number is the position of the order in the input, counting from 1
+	Number int   `json:"number"`
+	Order  []int `json:"order"`
+	validationResult[int]
+}
+
+// report holds the validation results of every print order, and the answer they add up to.
+type report struct {
+	Orders            []orderReport `json:"orders"`
+	ValidOrders       int           `json:"validOrders"`
+	InvalidOrders     int           `json:"invalidOrders"`
+	SumOfValidMiddles int           `json:"sumOfValidMiddles"`
+}
+
+// buildReport validates every print order against the rules, writing the rules they break in the format.
+func buildReport(printOrders [][]int, rules map[int][]int, format inputFormat) report {
+	r := report{Orders: make([]orderReport, 0, len(printOrders))}
+	for i, order := range printOrders {
+		result := validatePrintOrder(order, rules)
+		for j, v := range result.Violations {
+			result.Violations[j].Rule = format.formatRule(v.Page, v.EarlierPage)
+		}
+		r.Orders = append(r.Orders, orderReport{Number: i + 1, Order: order, validationResult: result})
+
+		if result.Valid {
+			r.ValidOrders++
+			r.SumOfValidMiddles += result.Middle
+		} else {
+			r.InvalidOrders++
+		}
+	}
+	return r
+}
+
+// writeReport writes the report in the named format, which is text or json.
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case "text":
+		return writeTextReport(w, r)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	return fmt.Errorf("unknown report format %q, expected text or json", format)
+}
+
+// writeTextReport writes a line for each print order, followed by a line for each rule an invalid order breaks.
+func writeTextReport(w io.Writer, r report) error {
+	out := bufio.NewWriter(w)
+
+	for _, order := range r.Orders {
+		if order.Valid {
+			fmt.Fprintf(out, "Order %d (%s) is valid, with middle page %d\n", order.Number, joinPages(order.Order), order.Middle)
+			continue
+		}
+
+		rules := "rules"
+		if len(order.Violations) == 1 {
+			rules = "rule"
+		}
+		fmt.Fprintf(out, "Order %d (%s) is invalid, breaking %d %s:\n", order.Number, joinPages(order.Order),
+			len(order.Violations), rules)
+		for _, v := range order.Violations {
+			fmt.Fprintf(out, "  %d at position %d is printed after %d at position %d, breaking rule %s\n",
+				v.Page, v.Position, v.EarlierPage, v.EarlierPosition, v.Rule)
+		}
+	}
+
+	fmt.Fprintf(out, "%d of %d orders are valid, and the sum of their middle pages is %d\n",
+		r.ValidOrders, len(r.Orders), r.SumOfValidMiddles)
+	return out.Flush()
+}
+
+// joinPages writes the pages of an order the way they are written in the input.
+func joinPages(pages []int) string {
+	return joinPagesWith(pages, ",")
+}
+
+// joinPagesWith writes the pages with the separator between them.
+func joinPagesWith(pages []int, separator string) string {
+	formatted := make([]string, len(pages))
+	for i, page := range pages {
+		formatted[i] = strconv.Itoa(page)
+	}
+	return strings.Join(formatted, separator)
+}